@@ -0,0 +1,138 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"kconsole/config"
+	"kconsole/utils/errorx"
+	"sort"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// namespaceListParallel the maximum number of namespaces listed at the same time
+const namespaceListParallel = 8
+
+// listNamespaceNames list the names of all namespaces
+func listNamespaceNames() ([]string, error) {
+	namespaces, err := getClientSet().CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		names = append(names, ns.Name)
+	}
+	return names, nil
+}
+
+// contextNamespace return the namespace of the current context of ~/.kube/config
+func contextNamespace() string {
+	if config.GetKconsoleConfig().Auth != config.LocalConfigAuth {
+		return ""
+	}
+	kubeconfig, err := clientcmd.LoadFromFile(kubeConfigPath())
+	if err != nil {
+		return ""
+	}
+	if ctx, ok := kubeconfig.Contexts[currentClusterName()]; ok {
+		return ctx.Namespace
+	}
+	return ""
+}
+
+// accessibleNamespaces discover the namespaces a user without cluster-wide rights can access:
+// the namespaces configured for the cluster, the namespaces listed by the apiserver, or the
+// default namespace of the cluster and of the kube context.
+func accessibleNamespaces() []string {
+	cc := config.GetKconsoleConfig().GetClusterConfig(currentClusterName())
+	if len(cc.Namespaces) > 0 {
+		return cc.Namespaces
+	}
+	namespaces, err := listNamespaceNames()
+	if err == nil {
+		return namespaces
+	}
+	if !k8serror.IsForbidden(err) {
+		errorx.CheckError(err)
+	}
+	namespaces = nil
+	for _, ns := range []string{cc.Namespace, contextNamespace()} {
+		if ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	if len(namespaces) == 0 {
+		errorx.CheckErrorWithCode(fmt.Errorf("listing pods of all namespaces is forbidden, use --namespace "+
+			"or configure the namespaces you can access with 'kconsole namespace --accessible ns1,ns2'"), errorx.ErrorArgsErr)
+	}
+	return namespaces
+}
+
+// podListByNamespaces list pods of the namespaces concurrently and merge them,
+// namespaces where listing pods is forbidden are skipped
+func podListByNamespaces(namespaces []string, opts metav1.ListOptions) *v1.PodList {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		sem    = make(chan struct{}, namespaceListParallel)
+		merged = &v1.PodList{}
+		errs   []error
+	)
+	for _, namespace := range namespaces {
+		wg.Add(1)
+		go func(namespace string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			pods, err := getClientSet().CoreV1().Pods(namespace).List(context.Background(), opts)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case k8serror.IsForbidden(err):
+			case err != nil:
+				errs = append(errs, err)
+			default:
+				merged.Items = append(merged.Items, pods.Items...)
+			}
+		}(namespace)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		errorx.CheckError(err)
+	}
+	sort.Slice(merged.Items, func(i, j int) bool {
+		a, b := merged.Items[i], merged.Items[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return merged
+}
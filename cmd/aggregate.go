@@ -0,0 +1,339 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pterm/pterm"
+	v1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+// logTail a running log stream of a container
+type logTail struct {
+	cancel context.CancelFunc
+}
+
+// logAggregator tail the logs of the containers of all pods matching the list options, starting on the
+// containers as they run and stopping on the pods as they are deleted. Lines are written in arrival order,
+// prefixed by a colourised pod/container.
+type logAggregator struct {
+	namespace string
+	listOpts  metav1.ListOptions
+	// container only tail the container of this name if set
+	container string
+	// logOpts the options of the tails, TailLines only applies to the containers running at start
+	logOpts *v1.PodLogOptions
+	// render the rendering of the lines, nil to write them as they are
+	render *logRenderOptions
+	out    io.Writer
+	// pods, namespaces and stream reach the cluster, replaced in tests
+	pods       func(namespace string) typedcorev1.PodInterface
+	namespaces func() []string
+	stream     func(ctx context.Context, namespace, podname string, opts *v1.PodLogOptions, out io.Writer) error
+
+	// outMu serialise the lines written to out
+	outMu sync.Mutex
+	mu    sync.Mutex
+	tails map[string]*logTail
+	// ended the time the stream of a container ended, a restarted container is tailed from there
+	ended map[string]metav1.Time
+	// colors the colour of the prefixes of each pod by namespace/name
+	colors    map[string]pterm.Color
+	nextColor int
+	wg        sync.WaitGroup
+}
+
+func newLogAggregator(namespace string, listOpts metav1.ListOptions, container string, logOpts *v1.PodLogOptions, render *logRenderOptions, out io.Writer) *logAggregator {
+	return &logAggregator{
+		namespace: namespace,
+		listOpts:  listOpts,
+		container: container,
+		logOpts:   logOpts,
+		render:    render,
+		out:       out,
+		pods: func(namespace string) typedcorev1.PodInterface {
+			return getClientSet().CoreV1().Pods(namespace)
+		},
+		namespaces: accessibleNamespaces,
+		stream:     streamLogs,
+		tails:      make(map[string]*logTail),
+		ended:      make(map[string]metav1.Time),
+		colors:     make(map[string]pterm.Color),
+	}
+}
+
+// aggregateListOptions return the namespace and list options of the pods of the target to tail together,
+// the target must name pods by a selector, pod, workload or service
+func aggregateListOptions(target Target) (string, metav1.ListOptions, error) {
+	switch {
+	case target.Service != "":
+		ns, name, err := parseServiceRef(target.Service, resolveNamespace(target))
+		if err != nil {
+			return "", metav1.ListOptions{}, err
+		}
+		svc, err := getClientSet().CoreV1().Services(ns).Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return "", metav1.ListOptions{}, err
+		}
+		if len(svc.Spec.Selector) == 0 {
+			return "", metav1.ListOptions{}, fmt.Errorf("service %s/%s has no selector, its pods can not be watched", ns, name)
+		}
+		return ns, metav1.ListOptions{LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String()}, nil
+	case target.byWorkload():
+		w := resolveWorkload(target)
+		selector, err := metav1.LabelSelectorAsSelector(w.Selector)
+		if err != nil {
+			return "", metav1.ListOptions{}, err
+		}
+		return w.Namespace, metav1.ListOptions{LabelSelector: selector.String()}, nil
+	case target.Selector != "" || target.FieldSelector != "" || target.Pod != "":
+		return resolveNamespace(target), podListOptions(target), nil
+	}
+	return "", metav1.ListOptions{}, fmt.Errorf("tailing several pods needs a --selector, --field-selector, --pod, --workload or --service")
+}
+
+// TailPodLogs follow the logs of all containers of the target's pods together until interrupted,
+// rendered unless render is nil
+func TailPodLogs(ctx context.Context, target Target, opts *v1.PodLogOptions, render *logRenderOptions) error {
+	namespace, listOpts, err := aggregateListOptions(target)
+	if err != nil {
+		return err
+	}
+	return newLogAggregator(namespace, listOpts, target.Container, opts, render, os.Stdout).run(ctx)
+}
+
+// run tail the pods listed at start, then follow the pods through a watch until ctx is done or the watch fails.
+// Without a namespace the pods of all namespaces are watched, or those of the accessible namespaces when
+// that is forbidden.
+func (a *logAggregator) run(ctx context.Context) error {
+	// the tails are derived from ctx, cancelling it ends them when the watch fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer a.wg.Wait()
+	defer cancel()
+
+	lists := make(map[string]*v1.PodList)
+	list, err := a.pods(a.namespace).List(ctx, a.listOpts)
+	switch {
+	case a.namespace == "" && k8serror.IsForbidden(err):
+		for _, namespace := range a.namespaces() {
+			list, err := a.pods(namespace).List(ctx, a.listOpts)
+			if k8serror.IsForbidden(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			lists[namespace] = list
+		}
+		if len(lists) == 0 {
+			return fmt.Errorf("listing pods is forbidden in all accessible namespaces, use --namespace")
+		}
+	case err != nil:
+		return err
+	default:
+		lists[a.namespace] = list
+	}
+
+	matched := 0
+	for _, list := range lists {
+		matched += len(list.Items)
+		for i := range list.Items {
+			a.sync(ctx, &list.Items[i], true)
+		}
+	}
+	if matched == 0 {
+		logNotice.Printfln("no pod matches yet, waiting for pods")
+	}
+	errs := make(chan error, len(lists))
+	for namespace, list := range lists {
+		go func(namespace, resourceVersion string) {
+			errs <- a.watch(ctx, namespace, resourceVersion)
+		}(namespace, list.ResourceVersion)
+	}
+	for range lists {
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watch follow the pods of the namespace from the resource version until ctx is done
+func (a *logAggregator) watch(ctx context.Context, namespace, resourceVersion string) error {
+	pods := a.pods(namespace)
+	watcher, err := watchtools.NewRetryWatcher(resourceVersion, &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = a.listOpts.LabelSelector
+			options.FieldSelector = a.listOpts.FieldSelector
+			return pods.Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("the watch of the pods is closed")
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if pod, ok := event.Object.(*v1.Pod); ok {
+					a.sync(ctx, pod, false)
+				}
+			case watch.Deleted:
+				if pod, ok := event.Object.(*v1.Pod); ok {
+					a.stop(pod)
+				}
+			case watch.Error:
+				return k8serror.FromObject(event.Object)
+			}
+		}
+	}
+}
+
+// tailedContainers return the containers of the pod to tail now: the running init containers (such as the
+// restartable sidecars) and app containers, or the one named
+func tailedContainers(pod *v1.Pod, container string) []string {
+	var names []string
+	running := func(containers []v1.Container, statuses []v1.ContainerStatus) {
+		for _, c := range containers {
+			if container != "" && c.Name != container {
+				continue
+			}
+			if status := findContainerStatus(statuses, c.Name); status != nil && status.State.Running != nil {
+				names = append(names, c.Name)
+			}
+		}
+	}
+	running(pod.Spec.InitContainers, pod.Status.InitContainerStatuses)
+	running(pod.Spec.Containers, pod.Status.ContainerStatuses)
+	return names
+}
+
+// sync start tailing the running containers of the pod that are not tailed yet. The containers running
+// at start are tailed from the last lines, the others from their first line.
+func (a *logAggregator) sync(ctx context.Context, pod *v1.Pod, atStart bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, container := range tailedContainers(pod, a.container) {
+		key := pod.Namespace + "/" + pod.Name + "/" + container
+		if _, ok := a.tails[key]; ok {
+			continue
+		}
+		opts := a.logOpts.DeepCopy()
+		opts.Container = container
+		opts.Follow = true
+		if !atStart {
+			opts.TailLines = nil
+		}
+		if since, ok := a.ended[key]; ok {
+			opts.TailLines = nil
+			opts.SinceSeconds = nil
+			opts.SinceTime = &since
+		}
+		podKey := pod.Namespace + "/" + pod.Name
+		color, ok := a.colors[podKey]
+		if !ok {
+			color = prefixColors[a.nextColor%len(prefixColors)]
+			a.colors[podKey] = color
+			a.nextColor++
+		}
+		prefix := pterm.NewStyle(color).Sprintf("[%s/%s]", pod.Name, container)
+
+		tailCtx, cancel := context.WithCancel(ctx)
+		tail := &logTail{cancel: cancel}
+		a.tails[key] = tail
+		a.wg.Add(1)
+		logNotice.Printfln("+ %s", key)
+		go a.tail(tailCtx, tail, pod.Namespace, pod.Name, key, prefix, opts)
+	}
+}
+
+// tail stream the logs of a container until it ends or is cancelled
+func (a *logAggregator) tail(ctx context.Context, tail *logTail, namespace, podname, key, prefix string, opts *v1.PodLogOptions) {
+	defer a.wg.Done()
+	w := newPrefixWriter(&a.outMu, a.out, prefix)
+	var out io.Writer = w
+	var renderer *logRenderer
+	if a.render != nil {
+		renderer = a.render.newWriter(w)
+		out = renderer
+	}
+	err := a.stream(ctx, namespace, podname, opts, out)
+	if renderer != nil {
+		renderer.Flush()
+	}
+	w.Flush()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.tails[key] == tail {
+		delete(a.tails, key)
+		if ctx.Err() == nil {
+			a.ended[key] = metav1.Now()
+		}
+	}
+	if err != nil && ctx.Err() == nil {
+		logNotice.Printfln("log stream of %s broke: %v", key, err)
+	}
+}
+
+// stop cancel the tails of the deleted pod
+func (a *logAggregator) stop(pod *v1.Pod) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	prefix := pod.Namespace + "/" + pod.Name + "/"
+	for key, tail := range a.tails {
+		if strings.HasPrefix(key, prefix) {
+			tail.cancel()
+			delete(a.tails, key)
+		}
+	}
+	for key := range a.ended {
+		if strings.HasPrefix(key, prefix) {
+			delete(a.ended, key)
+		}
+	}
+	delete(a.colors, pod.Namespace+"/"+pod.Name)
+	logNotice.Printfln("- %s", strings.TrimSuffix(prefix, "/"))
+}
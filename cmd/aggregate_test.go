@@ -0,0 +1,163 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestTailedContainers(t *testing.T) {
+	running := v1.ContainerState{Running: &v1.ContainerStateRunning{}}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app"}, {Name: "istio-proxy"}, {Name: "worker"}}},
+		Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{
+			{Name: "app", State: running},
+			{Name: "istio-proxy", State: running},
+			{Name: "worker", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+		}},
+	}
+	assert.Equal(t, []string{"app", "istio-proxy"}, tailedContainers(pod, ""))
+	assert.Equal(t, []string{"app"}, tailedContainers(pod, "app"))
+	assert.Empty(t, tailedContainers(pod, "worker"))
+
+	// a restartable sidecar init container keeps running next to the app, a completed one is skipped
+	pod = &v1.Pod{
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "init-db"}, {Name: "log-shipper"}},
+			Containers:     []v1.Container{{Name: "app"}},
+		},
+		Status: v1.PodStatus{
+			InitContainerStatuses: []v1.ContainerStatus{
+				{Name: "init-db", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Completed"}}},
+				{Name: "log-shipper", State: running},
+			},
+			ContainerStatuses: []v1.ContainerStatus{{Name: "app", State: running}},
+		},
+	}
+	assert.Equal(t, []string{"log-shipper", "app"}, tailedContainers(pod, ""))
+	assert.Equal(t, []string{"log-shipper"}, tailedContainers(pod, "log-shipper"))
+	assert.Empty(t, tailedContainers(pod, "init-db"))
+}
+
+func TestAggregateListOptions(t *testing.T) {
+	ns, opts, err := aggregateListOptions(Target{Namespace: "prod", Selector: "app=api"})
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", ns)
+	assert.Equal(t, "app=api", opts.LabelSelector)
+
+	_, _, err = aggregateListOptions(Target{Namespace: "prod"})
+	assert.Error(t, err)
+}
+
+func runningPod(namespace, name string) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app"}}},
+		Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{
+			{Name: "app", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+		}},
+	}
+}
+
+// newFakeAggregator return an aggregator on a fake cluster holding the pods of each namespace, listing the
+// namespaces missing from pods is forbidden. The log streams block until cancelled.
+func newFakeAggregator(namespace string, pods map[string][]v1.Pod) (*logAggregator, chan *watch.FakeWatcher) {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		items, ok := pods[action.GetNamespace()]
+		if !ok {
+			return true, nil, k8serror.NewForbidden(v1.Resource("pods"), "", nil)
+		}
+		return true, &v1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}, Items: items}, nil
+	})
+	watchers := make(chan *watch.FakeWatcher, 10)
+	clientset.PrependWatchReactor("pods", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		w := watch.NewFake()
+		watchers <- w
+		return true, w, nil
+	})
+	a := newLogAggregator(namespace, metav1.ListOptions{}, "", &v1.PodLogOptions{}, nil, &bytes.Buffer{})
+	a.pods = clientset.CoreV1().Pods
+	a.namespaces = func() []string { return []string{"a", "b", "c"} }
+	a.stream = func(ctx context.Context, namespace, podname string, opts *v1.PodLogOptions, out io.Writer) error {
+		<-ctx.Done()
+		return nil
+	}
+	return a, watchers
+}
+
+func TestLogAggregatorWatchError(t *testing.T) {
+	a, watchers := newFakeAggregator("prod", map[string][]v1.Pod{"prod": {runningPod("prod", "api-1")}})
+	done := make(chan error, 1)
+	go func() { done <- a.run(context.Background()) }()
+
+	w := <-watchers
+	w.Error(&metav1.Status{Status: metav1.StatusFailure, Code: 410, Reason: metav1.StatusReasonGone})
+	select {
+	case err := <-done:
+		// the tail of api-1 is cancelled instead of streaming until Ctrl-C
+		assert.True(t, k8serror.IsGone(err))
+	case <-time.After(5 * time.Second):
+		t.Fatal("the aggregator did not end after the watch failed")
+	}
+}
+
+func TestLogAggregatorAccessibleNamespaces(t *testing.T) {
+	a, watchers := newFakeAggregator("", map[string][]v1.Pod{
+		"a": {runningPod("a", "api")},
+		"c": {runningPod("c", "api")},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.run(ctx) }()
+	<-watchers
+	<-watchers
+
+	a.mu.Lock()
+	assert.Len(t, a.tails, 2)
+	assert.Len(t, a.colors, 2)
+	assert.NotEqual(t, a.colors["a/api"], a.colors["c/api"])
+	a.mu.Unlock()
+
+	pod := runningPod("a", "api")
+	a.stop(&pod)
+	a.mu.Lock()
+	assert.Contains(t, a.colors, "c/api")
+	assert.NotContains(t, a.colors, "a/api")
+	a.mu.Unlock()
+
+	cancel()
+	assert.NoError(t, <-done)
+}
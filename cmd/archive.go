@@ -0,0 +1,298 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// archiveManifestName the name of the manifest in the log archive
+const archiveManifestName = "manifest.json"
+
+// archiveManifest describe the pods whose logs are in the archive
+type archiveManifest struct {
+	Cluster   string        `json:"cluster"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Pods      []*archivePod `json:"pods"`
+}
+
+type archivePod struct {
+	Namespace  string              `json:"namespace"`
+	Name       string              `json:"name"`
+	UID        string              `json:"uid"`
+	Node       string              `json:"node"`
+	Status     string              `json:"status"`
+	Containers []*archiveContainer `json:"containers"`
+}
+
+type archiveContainer struct {
+	Name         string        `json:"name"`
+	Kind         string        `json:"kind"`
+	RestartCount int32         `json:"restartCount"`
+	Logs         []*archiveLog `json:"logs"`
+}
+
+// archiveLog a log file of the archive, From and To are the times of its first and last lines
+type archiveLog struct {
+	File     string     `json:"file"`
+	Previous bool       `json:"previous"`
+	Bytes    int64      `json:"bytes"`
+	From     *time.Time `json:"from,omitempty"`
+	To       *time.Time `json:"to,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// archiveJob a log to collect, with its spool file once collected
+type archiveJob struct {
+	pod   *v1.Pod
+	opts  *v1.PodLogOptions
+	entry *archiveLog
+	spool string
+}
+
+// logArchiver collect the current and previous logs of all containers of pods into a tar.gz
+type logArchiver struct {
+	// opts the options of every log, e.g. the time window
+	opts *v1.PodLogOptions
+	// container only collect the container of this name if set
+	container string
+	// timestamps keep the timestamp of every line, they are always read to record the time ranges
+	timestamps bool
+	parallel   int
+	fetch      func(ctx context.Context, namespace, podname string, opts *v1.PodLogOptions) (io.ReadCloser, error)
+}
+
+// archiveJobs list the logs to collect and the manifest describing them: the log of every container,
+// and the previous log of the containers that restarted
+func (a *logArchiver) archiveJobs(cluster string, pods []v1.Pod, now time.Time) (*archiveManifest, []*archiveJob) {
+	manifest := &archiveManifest{Cluster: cluster, CreatedAt: now}
+	var jobs []*archiveJob
+	for i := range pods {
+		pod := &pods[i]
+		mp := &archivePod{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			UID:       string(pod.UID),
+			Node:      pod.Spec.NodeName,
+			Status:    podStatus(pod),
+		}
+		statuses := append(append(append([]v1.ContainerStatus{}, pod.Status.ContainerStatuses...),
+			pod.Status.InitContainerStatuses...), pod.Status.EphemeralContainerStatuses...)
+		for _, c := range podContainers(pod) {
+			if a.container != "" && c.Name != a.container {
+				continue
+			}
+			mc := &archiveContainer{Name: c.Name, Kind: c.Kind}
+			status := findContainerStatus(statuses, c.Name)
+			if status != nil {
+				mc.RestartCount = status.RestartCount
+			}
+			previous := []bool{false}
+			if status != nil && (status.RestartCount > 0 || status.LastTerminationState.Terminated != nil) {
+				previous = append(previous, true)
+			}
+			for _, prev := range previous {
+				file := fmt.Sprintf("%s/%s/%s.log", pod.Namespace, pod.Name, c.Name)
+				if prev {
+					file = fmt.Sprintf("%s/%s/%s.previous.log", pod.Namespace, pod.Name, c.Name)
+				}
+				opts := a.opts.DeepCopy()
+				opts.Container = c.Name
+				opts.Previous = prev
+				opts.Follow = false
+				opts.Timestamps = true
+				entry := &archiveLog{File: file, Previous: prev}
+				mc.Logs = append(mc.Logs, entry)
+				jobs = append(jobs, &archiveJob{pod: pod, opts: opts, entry: entry})
+			}
+			mp.Containers = append(mp.Containers, mc)
+		}
+		manifest.Pods = append(manifest.Pods, mp)
+	}
+	return manifest, jobs
+}
+
+// collect spool the log of the job to a temporary file, a failure is recorded in the manifest
+func (a *logArchiver) collect(ctx context.Context, job *archiveJob) {
+	err := func() error {
+		lr, err := a.fetch(ctx, job.pod.Namespace, job.pod.Name, job.opts)
+		if err != nil {
+			return err
+		}
+		defer lr.Close()
+		spool, err := os.CreateTemp("", "kconsole-log-*")
+		if err != nil {
+			return err
+		}
+		defer spool.Close()
+		job.spool = spool.Name()
+		w := newTimeRangeWriter(spool, a.timestamps)
+		if _, err := io.Copy(w, lr); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		job.entry.Bytes, job.entry.From, job.entry.To = w.written, w.from, w.to
+		return nil
+	}()
+	if err != nil {
+		job.entry.Error = err.Error()
+	}
+}
+
+// archivePods list the pods of the target to archive: the pods named by a selector, pod, workload or
+// service, or else all pods of the namespace
+func archivePods(target Target) ([]v1.Pod, error) {
+	if target.Selector == "" && target.FieldSelector == "" && target.Pod == "" && target.Service == "" && !target.byWorkload() {
+		target.Namespace = resolveNamespace(target)
+		if target.Namespace == "" && !target.AllNamespaces {
+			return nil, fmt.Errorf("archiving needs a namespace, --all-namespaces, or a --selector, --pod, --workload or --service")
+		}
+	}
+	pods := targetPods(target)
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no pod matches namespace=%q pod=%q selector=%q field-selector=%q",
+			target.Namespace, target.Pod, target.Selector, target.FieldSelector)
+	}
+	return pods, nil
+}
+
+// Archive collect the logs of the pods with at most a.parallel at a time, and write them with the manifest as a tar.gz to out
+func (a *logArchiver) Archive(ctx context.Context, cluster string, pods []v1.Pod, out io.Writer) (*archiveManifest, error) {
+	manifest, jobs := a.archiveJobs(cluster, pods, time.Now())
+	done := make(chan *archiveJob)
+	go func() {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, a.parallel)
+		for _, job := range jobs {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(job *archiveJob) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				a.collect(ctx, job)
+				done <- job
+			}(job)
+		}
+		wg.Wait()
+		close(done)
+	}()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	var err error
+	for job := range done {
+		// keep draining so the collectors finish and their spool files are removed
+		if err == nil && job.entry.Error == "" {
+			err = addSpoolToTar(tw, job.entry.File, job.spool, manifest.CreatedAt)
+		}
+		if job.spool != "" {
+			os.Remove(job.spool)
+		}
+	}
+	if err != nil {
+		return manifest, err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+	if err := addBytesToTar(tw, archiveManifestName, data, manifest.CreatedAt); err != nil {
+		return manifest, err
+	}
+	if err := tw.Close(); err != nil {
+		return manifest, err
+	}
+	return manifest, gz.Close()
+}
+
+// addSpoolToTar add the spooled log as name to the archive
+func addSpoolToTar(tw *tar.Writer, name string, spool string, modTime time.Time) error {
+	f, err := os.Open(spool)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: modTime}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// addBytesToTar add the data as name to the archive
+func addBytesToTar(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: modTime}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// timeRangeWriter record the times of the first and last lines of a log read with timestamps,
+// and write the lines without their timestamps unless keep
+type timeRangeWriter struct {
+	*lineWriter
+	out     io.Writer
+	keep    bool
+	from    *time.Time
+	to      *time.Time
+	written int64
+}
+
+func newTimeRangeWriter(out io.Writer, keep bool) *timeRangeWriter {
+	w := &timeRangeWriter{out: out, keep: keep}
+	w.lineWriter = newLineWriter(w.writeLine)
+	return w
+}
+
+func (w *timeRangeWriter) writeLine(line []byte) error {
+	if t, stamp, _, ok := splitTimestamp(string(line)); ok {
+		if w.from == nil {
+			w.from = &t
+		}
+		w.to = &t
+		if !w.keep {
+			line = line[len(stamp)+1:]
+		}
+	}
+	n, err := w.out.Write(line)
+	w.written += int64(n)
+	return err
+}
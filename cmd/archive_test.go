@@ -0,0 +1,116 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTimeRangeWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := newTimeRangeWriter(&out, false)
+	_, err := w.Write([]byte("2024-01-02T15:04:05.5Z starting\n2024-01-02T15:05:00Z"))
+	assert.NoError(t, err)
+	_, err = w.Write([]byte(" ready\nno timestamp\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "starting\nready\nno timestamp\n", out.String())
+	assert.Equal(t, int64(out.Len()), w.written)
+	assert.True(t, w.from.Equal(time.Date(2024, 1, 2, 15, 4, 5, 5e8, time.UTC)))
+	assert.True(t, w.to.Equal(time.Date(2024, 1, 2, 15, 5, 0, 0, time.UTC)))
+}
+
+func TestLogArchiverArchive(t *testing.T) {
+	pods := []v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "api-0", UID: "uid-1"},
+		Spec: v1.PodSpec{
+			NodeName:       "node-1",
+			InitContainers: []v1.Container{{Name: "migrate"}},
+			Containers:     []v1.Container{{Name: "app"}},
+		},
+		Status: v1.PodStatus{
+			Phase:                 v1.PodRunning,
+			InitContainerStatuses: []v1.ContainerStatus{{Name: "migrate", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{}}}},
+			ContainerStatuses:     []v1.ContainerStatus{{Name: "app", RestartCount: 2, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}}},
+		},
+	}}
+	archiver := &logArchiver{
+		opts:     &v1.PodLogOptions{},
+		parallel: 2,
+		fetch: func(ctx context.Context, namespace, podname string, opts *v1.PodLogOptions) (io.ReadCloser, error) {
+			assert.True(t, opts.Timestamps)
+			if opts.Container == "migrate" {
+				return nil, fmt.Errorf("log not found")
+			}
+			line := fmt.Sprintf("2024-01-02T15:04:05Z %s previous=%v\n", opts.Container, opts.Previous)
+			return io.NopCloser(strings.NewReader(line)), nil
+		},
+	}
+	var out bytes.Buffer
+	manifest, err := archiver.Archive(context.Background(), "prod-cluster", pods, &out)
+	assert.NoError(t, err)
+
+	gz, err := gzip.NewReader(&out)
+	assert.NoError(t, err)
+	files := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		assert.NoError(t, err)
+		files[hdr.Name] = string(data)
+	}
+	assert.Equal(t, "app previous=false\n", files["prod/api-0/app.log"])
+	assert.Equal(t, "app previous=true\n", files["prod/api-0/app.previous.log"])
+	assert.NotContains(t, files, "prod/api-0/migrate.log")
+
+	var decoded archiveManifest
+	assert.NoError(t, json.Unmarshal([]byte(files[archiveManifestName]), &decoded))
+	assert.Equal(t, "prod-cluster", decoded.Cluster)
+	assert.Len(t, decoded.Pods, 1)
+	pod := decoded.Pods[0]
+	assert.Equal(t, "uid-1", pod.UID)
+	assert.Equal(t, "node-1", pod.Node)
+	assert.Len(t, pod.Containers, 2)
+	assert.Equal(t, int32(2), pod.Containers[0].RestartCount)
+	assert.Len(t, pod.Containers[0].Logs, 2)
+	assert.NotNil(t, pod.Containers[0].Logs[0].From)
+	assert.Equal(t, "log not found", pod.Containers[1].Logs[0].Error)
+	assert.Equal(t, manifest.Pods[0].Name, pod.Name)
+}
@@ -0,0 +1,190 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"fmt"
+	"kconsole/config"
+	"kconsole/utils/errorx"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagCluster = "cluster"
+	flagAuth    = "auth"
+)
+
+type BookmarkCmd struct {
+	BaseCommand
+}
+
+func (cl *BookmarkCmd) Init() {
+	cl.command = &cobra.Command{
+		Use:   "bookmark",
+		Short: "Manage the bookmarks of frequently used targets.",
+		Long: "Manage the bookmarks of frequently used targets. A bookmark names a cluster, namespace, label selector, " +
+			"container and default command, and is used as @name wherever a target is accepted.",
+		Example: "  kconsole bookmark add api-prod -n prod -l app=api -c app -- bash\n  kconsole console @api-prod\n  kconsole bookmark list\n  kconsole bookmark rm api-prod",
+	}
+	cl.command.DisableFlagsInUseLine = true
+	cl.AddCommands(&BookmarkAddCmd{}, &BookmarkListCmd{}, &BookmarkRmCmd{})
+}
+
+type BookmarkAddCmd struct {
+	BaseCommand
+}
+
+func (cl *BookmarkAddCmd) Init() {
+	cl.command = &cobra.Command{
+		Use:   "add NAME [-- command...]",
+		Short: "Add or replace a bookmark.",
+		Long: "Add or replace a bookmark. The bookmark uses the current cluster unless --cluster is given, " +
+			"the command after `--` is run by console and exec when they are given no command.",
+		Example: "  kconsole bookmark add api-prod -n prod -l app=api -c app -- bash\n  kconsole bookmark add db --cluster prod-context -n db -c postgres -- psql",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cl.runAdd(cmd, args)
+		},
+	}
+	flags := cl.command.Flags()
+	flags.StringP(flagNamespace, "n", "", "namespace of the pods")
+	flags.StringP(flagSelector, "l", "", "label selector of the pods, e.g. app=nginx")
+	flags.StringP(flagContainer, "c", "", "name of the container")
+	flags.String(flagCluster, "", "kube context or bcs cluster id, the current cluster if not set")
+	flags.String(flagAuth, "", "auth backend of the cluster, local or bcs, the current one if not set")
+}
+
+func (cl BookmarkAddCmd) runAdd(cmd *cobra.Command, args []string) error {
+	dash := cmd.ArgsLenAtDash()
+	if dash < 0 {
+		dash = len(args)
+	}
+	if dash != 1 {
+		return fmt.Errorf("expected one bookmark name before '--', got %v", args[:dash])
+	}
+	name := strings.TrimPrefix(args[0], bookmarkPrefix)
+	if name == "" || strings.ContainsAny(name, "/ ") {
+		return fmt.Errorf("invalid bookmark name %q", args[0])
+	}
+	b := config.Bookmark{Name: name, Command: args[dash:]}
+	flags := cmd.Flags()
+	for flag, field := range map[string]*string{
+		flagNamespace: &b.Namespace,
+		flagSelector:  &b.Selector,
+		flagContainer: &b.Container,
+		flagCluster:   &b.Cluster,
+		flagAuth:      &b.Auth,
+	} {
+		val, err := flags.GetString(flag)
+		errorx.CheckError(err)
+		*field = val
+	}
+	if b.Auth != "" && b.Auth != config.LocalConfigAuth && b.Auth != config.BcsAuth {
+		return fmt.Errorf("auth:%s is not vaild, must be the `bcs` or `local`", b.Auth)
+	}
+	if b.Auth == "" {
+		b.Auth = config.GetKconsoleConfig().Auth
+	}
+	if b.Cluster == "" && b.Auth == config.GetKconsoleConfig().Auth {
+		b.Cluster = currentClusterName()
+	}
+	config.SetBookmark(b)
+	fmt.Printf("bookmark @%s: %s~\n", b.Name, bookmarkSummary(b))
+	return nil
+}
+
+type BookmarkListCmd struct {
+	BaseCommand
+}
+
+func (cl *BookmarkListCmd) Init() {
+	cl.command = &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the bookmarks.",
+		Long:    "List the bookmarks.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cl.runList(cmd, args)
+		},
+	}
+}
+
+func (cl BookmarkListCmd) runList(cmd *cobra.Command, args []string) error {
+	bookmarks := config.GetKconsoleConfig().Bookmarks
+	if len(bookmarks) == 0 {
+		fmt.Println("no bookmark, add one with 'kconsole bookmark add'~")
+		return nil
+	}
+	data := pterm.TableData{{"NAME", "AUTH", "CLUSTER", "NAMESPACE", "SELECTOR", "CONTAINER", "COMMAND"}}
+	for _, b := range bookmarks {
+		data = append(data, []string{"@" + b.Name, b.Auth, b.Cluster, b.Namespace, b.Selector, b.Container, strings.Join(b.Command, " ")})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
+
+type BookmarkRmCmd struct {
+	BaseCommand
+}
+
+func (cl *BookmarkRmCmd) Init() {
+	cl.command = &cobra.Command{
+		Use:     "rm NAME...",
+		Aliases: []string{"remove"},
+		Short:   "Remove bookmarks.",
+		Long:    "Remove bookmarks.",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cl.runRm(cmd, args)
+		},
+	}
+}
+
+func (cl BookmarkRmCmd) runRm(cmd *cobra.Command, args []string) error {
+	for _, arg := range args {
+		name := strings.TrimPrefix(arg, bookmarkPrefix)
+		if !config.RemoveBookmark(name) {
+			return fmt.Errorf("bookmark %q not found", name)
+		}
+		fmt.Printf("removed bookmark @%s~\n", name)
+	}
+	return nil
+}
+
+// bookmarkSummary render the bookmark as the flags it stands for
+func bookmarkSummary(b config.Bookmark) string {
+	parts := []string{}
+	for _, part := range [][2]string{
+		{"--" + flagAuth, b.Auth}, {"--" + flagCluster, b.Cluster}, {"-n", b.Namespace}, {"-l", b.Selector}, {"-c", b.Container},
+	} {
+		if part[1] != "" {
+			parts = append(parts, part[0]+" "+part[1])
+		}
+	}
+	if len(b.Command) > 0 {
+		parts = append(parts, "-- "+strings.Join(b.Command, " "))
+	}
+	return strings.Join(parts, " ")
+}
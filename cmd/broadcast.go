@@ -0,0 +1,259 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"kconsole/config"
+	"kconsole/utils/errorx"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/exec"
+)
+
+const (
+	flagParallel = "parallel"
+)
+
+// prefixColors the colours used in turn for the prefixes of the pods
+var prefixColors = []pterm.Color{
+	pterm.FgCyan, pterm.FgGreen, pterm.FgMagenta, pterm.FgYellow, pterm.FgBlue,
+	pterm.FgLightCyan, pterm.FgLightGreen, pterm.FgLightMagenta, pterm.FgLightYellow, pterm.FgLightBlue,
+}
+
+// prefixWriter write every line with a prefix, lines of writers sharing the mutex never interleave.
+type prefixWriter struct {
+	*lineWriter
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+}
+
+func newPrefixWriter(mu *sync.Mutex, out io.Writer, prefix string) *prefixWriter {
+	w := &prefixWriter{mu: mu, out: out, prefix: prefix}
+	w.lineWriter = newLineWriter(w.writeLine)
+	return w
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := fmt.Fprintf(w.out, "%s %s\n", w.prefix, bytes.TrimSuffix(line, []byte("\n")))
+	return err
+}
+
+// broadcastResult the outcome of the command in one container
+type broadcastResult struct {
+	target   string
+	exitCode int
+	err      error
+}
+
+type BroadcastCmd struct {
+	BaseCommand
+}
+
+func (cl *BroadcastCmd) Init() {
+	cl.command = &cobra.Command{
+		Use:   "broadcast",
+		Short: "Run a command in many pods concurrently.",
+		Long: "Run a command in the pods matching a label selector, or in the pods selected from a menu, concurrently. " +
+			"Every output line is prefixed by ns/pod/container and an exit code summary is printed at the end.",
+		Example: "  kconsole broadcast -n default -l app=nginx -- cat /etc/nginx/nginx.conf\n  kconsole broadcast --parallel 10 -- curl -s localhost:8080/health\n  kconsole broadcast @api-prod -- env",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cl.runBroadcast(cmd, args)
+		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cl.command.DisableFlagsInUseLine = true
+	addListFlags(cl.command)
+	flags := cl.command.Flags()
+	flags.StringP(flagContainer, "c", "", "name of the container, the default container of each pod if not set")
+	flags.Int(flagParallel, 5, "maximum number of pods running the command at the same time")
+}
+
+// selectPods return the running pods matching the selector, service or workload, or those chosen from a multi-select menu.
+func (cl BroadcastCmd) selectPods(target Target) []v1.Pod {
+	pods := targetPods(target)
+	running := make([]v1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if pod.Status.Phase == v1.PodRunning {
+			running = append(running, pod)
+		}
+	}
+	if target.Selector != "" || target.Service != "" || target.byWorkload() {
+		return running
+	}
+	options := make([]string, 0, len(running))
+	byName := make(map[string]v1.Pod, len(running))
+	for _, pod := range running {
+		name := fmt.Sprintf("%s#%s", pod.Namespace, pod.Name)
+		options = append(options, name)
+		byName[name] = pod
+	}
+	selected, err := pterm.DefaultInteractiveMultiselect.WithOptions(options).Show("select pods (enter to toggle, tab to confirm)")
+	errorx.CheckErrorWithCode(err, errorx.ErrorSelectExit)
+	chosen := make([]v1.Pod, 0, len(selected))
+	for _, name := range selected {
+		chosen = append(chosen, byName[name])
+	}
+	return chosen
+}
+
+// splitCommand split args into an optional @bookmark before `--` and the command after it,
+// the command of the bookmark is used when none is given.
+func (cl BroadcastCmd) splitCommand(cmd *cobra.Command, args []string) (targetArgs, command []string, err error) {
+	dash := cmd.ArgsLenAtDash()
+	if dash < 0 {
+		dash = 0
+		if len(args) == 1 && isBookmarkRef(args[0]) {
+			dash = 1
+		}
+	}
+	if dash > 1 || (dash == 1 && !isBookmarkRef(args[0])) {
+		return nil, nil, fmt.Errorf("expected at most one @bookmark before '--', got %v", args[:dash])
+	}
+	targetArgs, command = args[:dash], args[dash:]
+	if len(command) == 0 {
+		command = bookmarkCommand(targetArgs)
+	}
+	if len(command) == 0 {
+		return nil, nil, fmt.Errorf("missing command, usage: kconsole broadcast [@bookmark] [flags] -- cmd args...")
+	}
+	return targetArgs, command, nil
+}
+
+func (cl BroadcastCmd) runBroadcast(cmd *cobra.Command, args []string) error {
+	targetArgs, command, err := cl.splitCommand(cmd, args)
+	if err != nil {
+		return err
+	}
+	flags := cmd.Flags()
+	target, err := targetFromFlags(cmd, targetArgs)
+	errorx.CheckErrorWithCode(err, errorx.ErrorArgsErr)
+	container := target.Container
+	parallel, err := flags.GetInt(flagParallel)
+	errorx.CheckError(err)
+	if parallel < 1 {
+		return fmt.Errorf("--%s must be at least 1", flagParallel)
+	}
+
+	pods := cl.selectPods(target)
+	if len(pods) == 0 {
+		return fmt.Errorf("no running pod selected")
+	}
+	results := Broadcast(pods, container, command, parallel)
+	return cl.printSummary(results)
+}
+
+// broadcastExec run the command in the container writing its output to stdout and stderr
+type broadcastExec func(namespace, podname, container string, command []string, stdout, stderr io.Writer) error
+
+// Broadcast run the command in the pods with at most parallel pods at a time.
+// An empty container means the default container of each pod, sidecars are skipped.
+func Broadcast(pods []v1.Pod, container string, command []string, parallel int) []broadcastResult {
+	sidecars := config.GetKconsoleConfig().SidecarNames()
+	return broadcast(pods, container, sidecars, command, parallel, os.Stdout, os.Stderr,
+		func(namespace, podname, container string, command []string, stdout, stderr io.Writer) error {
+			return execCommand(namespace, podname, container, command, nil, stdout, stderr)
+		})
+}
+
+// broadcast run the command in the pods through run, prefixing their output written to out and errOut.
+// Without a container the default container of each pod is used, skipping the sidecars.
+func broadcast(pods []v1.Pod, container string, sidecars []string, command []string, parallel int, out, errOut io.Writer, run broadcastExec) []broadcastResult {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, parallel)
+		results = make([]broadcastResult, len(pods))
+	)
+	for i, pod := range pods {
+		c := container
+		if c == "" {
+			c = primaryContainerName(&pod, sidecars)
+		}
+		target := fmt.Sprintf("%s/%s/%s", pod.Namespace, pod.Name, c)
+		prefix := pterm.NewStyle(prefixColors[i%len(prefixColors)]).Sprintf("[%s]", target)
+		stdout := newPrefixWriter(&mu, out, prefix)
+		stderr := newPrefixWriter(&mu, errOut, prefix)
+
+		wg.Add(1)
+		go func(i int, namespace, podname string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			err := run(namespace, podname, c, command, stdout, stderr)
+			_ = stdout.Flush()
+			_ = stderr.Flush()
+			results[i] = broadcastResult{target: target, err: err}
+			var exitErr exec.ExitError
+			if errors.As(err, &exitErr) && exitErr.Exited() {
+				results[i] = broadcastResult{target: target, exitCode: exitErr.ExitStatus()}
+			}
+		}(i, pod.Namespace, pod.Name)
+	}
+	wg.Wait()
+	return results
+}
+
+// printSummary print the exit code of every container, an error is returned if any of them failed
+func (cl BroadcastCmd) printSummary(results []broadcastResult) error {
+	data, failed := summaryTable(results)
+	pterm.Println()
+	if err := pterm.DefaultTable.WithHasHeader().WithData(data).Render(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("command failed in %d of %d containers", failed, len(results))
+	}
+	return nil
+}
+
+// summaryTable return the rows of the summary, failed exit codes in red, and the number of failed containers
+func summaryTable(results []broadcastResult) (pterm.TableData, int) {
+	data := pterm.TableData{{"TARGET", "EXIT CODE", "ERROR"}}
+	failed := 0
+	for _, result := range results {
+		code, message := strconv.Itoa(result.exitCode), ""
+		if result.err != nil {
+			code, message = "-", result.err.Error()
+		}
+		if result.err != nil || result.exitCode != 0 {
+			failed++
+			code = pterm.FgRed.Sprint(code)
+		}
+		data = append(data, []string{result.target, code, strings.TrimSpace(message)})
+	}
+	return data, failed
+}
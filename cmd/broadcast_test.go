@@ -0,0 +1,116 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/exec"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := newPrefixWriter(&sync.Mutex{}, buf, "[a]")
+
+	_, err := w.Write([]byte("hello\nwor"))
+	assert.NoError(t, err)
+	assert.Equal(t, "[a] hello\n", buf.String())
+
+	_, err = w.Write([]byte("ld\nlast"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "[a] hello\n[a] world\n[a] last\n", buf.String())
+}
+
+func TestBroadcast(t *testing.T) {
+	pods := []v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "api-0"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "api-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "api-2"}},
+	}
+	var out, errOut bytes.Buffer
+	results := broadcast(pods, "app", nil, []string{"health"}, 2, &out, &errOut,
+		func(namespace, podname, container string, command []string, stdout, stderr io.Writer) error {
+			switch podname {
+			case "api-0":
+				fmt.Fprintln(stdout, "ok")
+				return nil
+			case "api-1":
+				fmt.Fprintln(stderr, "unhealthy")
+				return exec.CodeExitError{Err: errors.New("command terminated with exit code 3"), Code: 3}
+			}
+			return errors.New("pods \"api-2\" is forbidden")
+		})
+
+	assert.Equal(t, []broadcastResult{
+		{target: "prod/api-0/app"},
+		{target: "prod/api-1/app", exitCode: 3},
+		{target: "prod/api-2/app", err: errors.New("pods \"api-2\" is forbidden")},
+	}, results)
+	assert.Equal(t, "[prod/api-0/app] ok", strings.TrimSpace(stripANSI(out.String())))
+	assert.Equal(t, "[prod/api-1/app] unhealthy", strings.TrimSpace(stripANSI(errOut.String())))
+
+	// without a container the default one of the pod is used, skipping the sidecars
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "web-0"},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "istio-proxy"}, {Name: "web"}}},
+	}
+	results = broadcast([]v1.Pod{pod}, "", []string{"istio-proxy"}, []string{"health"}, 1, &out, &errOut,
+		func(namespace, podname, container string, command []string, stdout, stderr io.Writer) error {
+			return nil
+		})
+	assert.Equal(t, []broadcastResult{{target: "prod/web-0/web"}}, results)
+}
+
+func TestSummaryTable(t *testing.T) {
+	data, failed := summaryTable([]broadcastResult{
+		{target: "prod/api-0/app"},
+		{target: "prod/api-1/app", exitCode: 3},
+		{target: "prod/api-2/app", err: errors.New("pods \"api-2\" is forbidden\n")},
+	})
+	assert.Equal(t, 2, failed)
+	var rows [][]string
+	for _, row := range data {
+		plain := make([]string, len(row))
+		for i, cell := range row {
+			plain[i] = stripANSI(cell)
+		}
+		rows = append(rows, plain)
+	}
+	assert.Equal(t, [][]string{
+		{"TARGET", "EXIT CODE", "ERROR"},
+		{"prod/api-0/app", "0", ""},
+		{"prod/api-1/app", "3", ""},
+		{"prod/api-2/app", "-", `pods "api-2" is forbidden`},
+	}, rows)
+	// a successful exit code is not coloured
+	assert.Equal(t, "0", data[1][1])
+}
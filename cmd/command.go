@@ -53,11 +53,17 @@ func NewBaseCommand() *BaseCommand {
 		command: cli.rootCmd,
 	}
 	baseCmd.AddCommands(&ConsoleCmd{})
+	baseCmd.AddCommands(&ExecCmd{})
+	baseCmd.AddCommands(&BroadcastCmd{})
+	baseCmd.AddCommands(&DebugCmd{})
 	baseCmd.AddCommands(&DownloadCmd{})
 	baseCmd.AddCommands(&UploadCmd{})
 	baseCmd.AddCommands(&LogCmd{})
 	baseCmd.AddCommands(&LoginCmd{})
 	baseCmd.AddCommands(&SwitchCmd{})
+	baseCmd.AddCommands(&NamespaceCmd{})
 	baseCmd.AddCommands(&LogDownCmd{})
+	baseCmd.AddCommands(&HistoryCmd{})
+	baseCmd.AddCommands(&BookmarkCmd{})
 	return baseCmd
 }
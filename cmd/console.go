@@ -22,10 +22,17 @@
 package cmd
 
 import (
+	"kconsole/config"
+	"kconsole/utils/errorx"
+	"strings"
+
+	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 )
 
-var CMDS = []string{"sh", "bash"}
+const (
+	flagShell = "shell"
+)
 
 type ConsoleCmd struct {
 	BaseCommand
@@ -33,21 +40,37 @@ type ConsoleCmd struct {
 
 func (cl *ConsoleCmd) Init() {
 	cl.command = &cobra.Command{
-		Use:   "console",
-		Short: "Exec a command for a container incluster.",
-		Long:  "Exec a command for a container incluster.",
+		Use:     "console",
+		Short:   "Exec a command for a container incluster.",
+		Long:    "Exec a command for a container incluster.",
+		Example: "  kconsole console\n  kconsole console default/nginx-0/nginx\n  kconsole console @api-prod\n  kconsole console -n default --pod nginx-0 -c nginx --shell /bin/sh",
+		Args:    cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return cl.runConsole(cmd, args)
 		},
 	}
 	cl.command.DisableFlagsInUseLine = true
+	addTargetFlags(cl.command)
+	cl.command.Flags().String(flagShell, "", "shell to run instead of the detected one, e.g. /bin/bash or 'busybox sh'")
 }
 
 func (cl ConsoleCmd) runConsole(cmd *cobra.Command, args []string) error {
-	podname, namespace, selectcontainer := SelectContainer()
-	// select command
-	selectcmd := SelectUI(CMDS, "select a cmd")
+	podname, namespace, selectcontainer := selectTarget(cmd, args)
+	// select shell
+	shellflag, err := cmd.Flags().GetString(flagShell)
+	errorx.CheckError(err)
+	shell := strings.Fields(shellflag)
+	if len(shell) == 0 {
+		shell = bookmarkCommand(args)
+	}
+	if len(shell) == 0 {
+		shell, err = DetectShell(namespace, podname, selectcontainer, config.GetKconsoleConfig().ShellOrder())
+		if err != nil {
+			return err
+		}
+		pterm.Info.Printfln("using shell %s", strings.Join(shell, " "))
+	}
 	// build exec real command
-	err := ExecPodContainer(namespace, podname, selectcontainer, selectcmd)
+	err = ExecPodContainer(namespace, podname, selectcontainer, shell)
 	return err
 }
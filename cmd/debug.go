@@ -0,0 +1,173 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"kconsole/config"
+	"kconsole/utils/errorx"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	flagImage = "image"
+	// debugStartTimeout how long to wait for the debug container to be running
+	debugStartTimeout = 3 * time.Minute
+)
+
+type DebugCmd struct {
+	BaseCommand
+}
+
+func (cl *DebugCmd) Init() {
+	cl.command = &cobra.Command{
+		Use:   "debug",
+		Short: "Debug a container incluster with an ephemeral toolbox container.",
+		Long: "Debug a container incluster with an ephemeral toolbox container. The toolbox shares the process namespace " +
+			"of the selected container, which helps with images that have no shell at all.",
+		Example: "  kconsole debug\n  kconsole debug default/nginx-0/nginx --image nicolaka/netshoot",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cl.runDebug(cmd, args)
+		},
+	}
+	cl.command.DisableFlagsInUseLine = true
+	addTargetFlags(cl.command)
+	cl.command.Flags().String(flagImage, "", fmt.Sprintf("toolbox image of the debug container (default %q or debugimage in config)", config.DefaultDebugImage))
+}
+
+func (cl DebugCmd) runDebug(cmd *cobra.Command, args []string) error {
+	podname, namespace, selectcontainer := selectTarget(cmd, args)
+	image, err := cmd.Flags().GetString(flagImage)
+	errorx.CheckError(err)
+	if image == "" {
+		image = config.GetKconsoleConfig().DebugImageOrDefault()
+	}
+	ctx := context.Background()
+	debugger, err := AddDebugContainer(ctx, namespace, podname, selectcontainer, image)
+	if err != nil {
+		return err
+	}
+	pterm.Info.Printfln("waiting for debug container %s to start", debugger)
+	if err = waitEphemeralContainerRunning(ctx, namespace, podname, debugger); err != nil {
+		return err
+	}
+	pterm.Info.Printfln("attached to %s, if you don't see a command prompt, try pressing enter", debugger)
+	executor, err := newAttachExecutor(namespace, podname, &v1.PodAttachOptions{
+		Container: debugger,
+		Stdin:     true,
+		Stdout:    true,
+		TTY:       true,
+	})
+	if err != nil {
+		return err
+	}
+	return streamTTY(ctx, executor)
+}
+
+// AddDebugContainer inject an ephemeral container running image into the pod, targeting the process
+// namespace of the container. The name of the ephemeral container is returned.
+func AddDebugContainer(ctx context.Context, namespace string, podname string, container string, image string) (string, error) {
+	pods := getClientSet().CoreV1().Pods(namespace)
+	pod, err := pods.Get(ctx, podname, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if err := checkDebugTarget(pod, container); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("debugger-%s", utilrand.String(5))
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, v1.EphemeralContainer{
+		EphemeralContainerCommon: v1.EphemeralContainerCommon{
+			Name:                     name,
+			Image:                    image,
+			ImagePullPolicy:          v1.PullIfNotPresent,
+			Stdin:                    true,
+			TTY:                      true,
+			TerminationMessagePolicy: v1.TerminationMessageReadFile,
+		},
+		TargetContainerName: container,
+	})
+	if _, err = pods.UpdateEphemeralContainers(ctx, podname, pod, metav1.UpdateOptions{}); err != nil {
+		return "", fmt.Errorf("add debug container to pod %s/%s: %w", namespace, podname, err)
+	}
+	return name, nil
+}
+
+// checkDebugTarget fail unless the container is a running app container of the pod. The API only targets app
+// containers, and the processes of a stopped one are gone.
+func checkDebugTarget(pod *v1.Pod, container string) error {
+	for _, c := range podContainers(pod) {
+		if c.Name != container {
+			continue
+		}
+		if c.Kind != ContainerKindApp {
+			return fmt.Errorf("container %q of pod %s/%s is an %s container, debug can only target an app container", container, pod.Namespace, pod.Name, c.Kind)
+		}
+		if status := findContainerStatus(pod.Status.ContainerStatuses, container); status == nil || status.State.Running == nil {
+			return fmt.Errorf("container %q of pod %s/%s is %s, debug needs it running", container, pod.Namespace, pod.Name, c.State)
+		}
+		return nil
+	}
+	return fmt.Errorf("container %q not found in pod %s/%s", container, pod.Namespace, pod.Name)
+}
+
+// waitEphemeralContainerRunning wait until the ephemeral container is running, failing fast if it cannot start
+func waitEphemeralContainerRunning(ctx context.Context, namespace string, podname string, container string) error {
+	return wait.PollUntilContextTimeout(ctx, time.Second, debugStartTimeout, true, func(ctx context.Context) (bool, error) {
+		pod, err := getClientSet().CoreV1().Pods(namespace).Get(ctx, podname, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, status := range pod.Status.EphemeralContainerStatuses {
+			if status.Name != container {
+				continue
+			}
+			switch {
+			case status.State.Running != nil:
+				return true, nil
+			case status.State.Terminated != nil:
+				return false, fmt.Errorf("debug container %s terminated: %s", container, status.State.Terminated.Reason)
+			case status.State.Waiting != nil && isImageError(status.State.Waiting.Reason):
+				return false, fmt.Errorf("debug container %s cannot start: %s %s", container, status.State.Waiting.Reason, status.State.Waiting.Message)
+			}
+		}
+		return false, nil
+	})
+}
+
+// isImageError report whether the waiting reason means the image will not be pulled without intervention
+func isImageError(reason string) bool {
+	switch reason {
+	case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull":
+		return true
+	}
+	return false
+}
@@ -25,29 +25,39 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const (
+	flagSrc  = "src"
+	flagDest = "dest"
+)
+
 type DownloadCmd struct {
 	BaseCommand
 }
 
 func (cl *DownloadCmd) Init() {
 	cl.command = &cobra.Command{
-		Use:   "download",
-		Short: "Copy files from container to local",
-		Long:  "Copy files from container to local",
+		Use:     "download",
+		Short:   "Copy files from container to local",
+		Long:    "Copy files from container to local",
+		Example: "  kconsole download\n  kconsole download default/nginx-0/nginx --src /etc/nginx/nginx.conf --dest ./",
+		Args:    cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return cl.runDownload(cmd, args)
 		},
 	}
 	cl.command.DisableFlagsInUseLine = true
+	addTargetFlags(cl.command)
+	cl.command.Flags().String(flagSrc, "", "container source file path")
+	cl.command.Flags().String(flagDest, "", "local destination path")
 }
 
 func (cl DownloadCmd) runDownload(cmd *cobra.Command, args []string) error {
 	// call utils get pods
-	podname, namespace, selectcontainer := SelectContainer()
+	podname, namespace, selectcontainer := selectTarget(cmd, args)
 	// input file
-	inputsourcecmd := InputUI("input container source file path", "/", "")
+	inputsourcecmd := flagOrInputUI(cmd, flagSrc, "input container source file path", "/", "")
 	// input file
-	inputdestcmd := InputUI("input container source file path", "local", "./")
+	inputdestcmd := flagOrInputUI(cmd, flagDest, "input container source file path", "local", "./")
 	// build exec real command
 	err := copyFromPod(namespace, podname, selectcontainer, inputsourcecmd, inputdestcmd)
 	return err
@@ -0,0 +1,372 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pterm/pterm"
+	v1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"k8s.io/kubectl/pkg/util/term"
+)
+
+const (
+	flagEvents = "events"
+)
+
+// eventFlushInterval the interval of writing the events that no newer log line has written yet while following
+const eventFlushInterval = time.Second
+
+// timelineEntry an event of the pod or a termination of the container, shown among the log lines
+type timelineEntry struct {
+	time time.Time
+	// key identify the entry, an entry seen again is not shown twice
+	key     string
+	text    string
+	warning bool
+}
+
+// logTimeline interleave the events with the log lines by time. The log lines are expected to be prefixed by
+// kubelet timestamps, the entries older than a line are written before it.
+type logTimeline struct {
+	mu sync.Mutex
+	// logOut the writer of the log lines, out the one of the entries
+	logOut io.Writer
+	out    io.Writer
+	color  bool
+	// timestamps keep the timestamps of the log lines
+	timestamps bool
+	pending    []timelineEntry
+	seen       map[string]bool
+	splitter   *lineWriter
+}
+
+func newLogTimeline(logOut, out io.Writer, color, timestamps bool) *logTimeline {
+	t := &logTimeline{logOut: logOut, out: out, color: color, timestamps: timestamps, seen: map[string]bool{}}
+	t.splitter = newLineWriter(t.writeLine)
+	return t
+}
+
+// add queue the entry until a newer log line is written, unless it was added before
+func (t *logTimeline) add(e timelineEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.seen[e.key] {
+		return
+	}
+	t.seen[e.key] = true
+	i := sort.Search(len(t.pending), func(i int) bool { return t.pending[i].time.After(e.time) })
+	t.pending = append(t.pending, timelineEntry{})
+	copy(t.pending[i+1:], t.pending[i:])
+	t.pending[i] = e
+}
+
+func (t *logTimeline) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.splitter.Write(p)
+}
+
+// writeLine write the entries up to the time of the line, then the line
+func (t *logTimeline) writeLine(line []byte) error {
+	ts, stamp, _, ok := splitTimestamp(string(line))
+	if ok {
+		if err := t.writeEntries(ts); err != nil {
+			return err
+		}
+		if !t.timestamps {
+			line = line[len(stamp)+1:]
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\n")) {
+		line = append(line, '\n')
+	}
+	_, err := t.logOut.Write(line)
+	return err
+}
+
+// writeEntries write the pending entries up to the time, all of them if the time is zero
+func (t *logTimeline) writeEntries(until time.Time) error {
+	n := 0
+	for ; n < len(t.pending); n++ {
+		e := t.pending[n]
+		if !until.IsZero() && e.time.After(until) {
+			break
+		}
+		if _, err := io.WriteString(t.out, t.format(e)+"\n"); err != nil {
+			return err
+		}
+	}
+	t.pending = t.pending[n:]
+	return nil
+}
+
+// FlushEntries write all pending entries, e.g. when no log line came for a while
+func (t *logTimeline) FlushEntries() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.writeEntries(time.Time{})
+}
+
+// Flush write the last line even if it is not terminated by a newline, then the pending entries
+func (t *logTimeline) Flush() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.splitter.Flush(); err != nil {
+		return err
+	}
+	return t.writeEntries(time.Time{})
+}
+
+// format render the entry as `>>> time text`, warnings in yellow and others in cyan
+func (t *logTimeline) format(e timelineEntry) string {
+	text := fmt.Sprintf(">>> %s %s", e.time.UTC().Format(time.RFC3339), e.text)
+	if !t.color {
+		return text
+	}
+	if e.warning {
+		return pterm.NewStyle(pterm.FgYellow, pterm.Bold).Sprint(text)
+	}
+	return pterm.NewStyle(pterm.FgCyan, pterm.Bold).Sprint(text)
+}
+
+// eventTime return the time an event last happened
+func eventTime(ev *v1.Event) time.Time {
+	switch {
+	case !ev.LastTimestamp.IsZero():
+		return ev.LastTimestamp.Time
+	case !ev.EventTime.IsZero():
+		return ev.EventTime.Time
+	case !ev.FirstTimestamp.IsZero():
+		return ev.FirstTimestamp.Time
+	}
+	return ev.CreationTimestamp.Time
+}
+
+// eventEntry return the entry of an event of the pod. The events of other containers are skipped,
+// those of the pod itself, e.g. scheduling, are kept.
+func eventEntry(ev *v1.Event, container string) (timelineEntry, bool) {
+	if path := ev.InvolvedObject.FieldPath; container != "" && path != "" && !strings.Contains(path, "{"+container+"}") {
+		return timelineEntry{}, false
+	}
+	text := fmt.Sprintf("event %s %s: %s", ev.Type, ev.Reason, strings.TrimSpace(ev.Message))
+	if ev.Count > 1 {
+		text += fmt.Sprintf(" (x%d)", ev.Count)
+	}
+	return timelineEntry{
+		time:    eventTime(ev),
+		key:     fmt.Sprintf("event/%s/%d", ev.UID, ev.Count),
+		text:    text,
+		warning: ev.Type == v1.EventTypeWarning,
+	}, true
+}
+
+// terminationEntries return the entries of the current and last terminations of the container recorded in the pod status
+func terminationEntries(pod *v1.Pod, container string) []timelineEntry {
+	var entries []timelineEntry
+	for _, statuses := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		status := findContainerStatus(statuses, container)
+		if status == nil {
+			continue
+		}
+		for _, terminated := range []*v1.ContainerStateTerminated{status.LastTerminationState.Terminated, status.State.Terminated} {
+			if terminated == nil || terminated.FinishedAt.IsZero() {
+				continue
+			}
+			text := fmt.Sprintf("container %s terminated: %s, exit code %d", container, terminated.Reason, terminated.ExitCode)
+			if terminated.Signal != 0 {
+				text += fmt.Sprintf(", signal %d", terminated.Signal)
+			}
+			if msg := strings.TrimSpace(terminated.Message); msg != "" {
+				text += ": " + msg
+			}
+			entries = append(entries, timelineEntry{
+				time:    terminated.FinishedAt.Time,
+				key:     fmt.Sprintf("terminated/%s/%s", container, terminated.FinishedAt.UTC().Format(time.RFC3339)),
+				text:    text,
+				warning: terminated.ExitCode != 0,
+			})
+		}
+	}
+	return entries
+}
+
+// podEventsSelector select the events of the pod
+func podEventsSelector(namespace, podname string) string {
+	return fmt.Sprintf("involvedObject.kind=Pod,involvedObject.namespace=%s,involvedObject.name=%s", namespace, podname)
+}
+
+// podTimeline list the events and terminations of the container into the timeline, returning the resource
+// versions to watch them from
+func podTimeline(ctx context.Context, timeline *logTimeline, namespace, podname, container string) (eventsVersion, podVersion string, err error) {
+	clientset := getClientSet()
+	events, err := clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{FieldSelector: podEventsSelector(namespace, podname)})
+	if err != nil {
+		return "", "", err
+	}
+	for i := range events.Items {
+		if e, ok := eventEntry(&events.Items[i], container); ok {
+			timeline.add(e)
+		}
+	}
+	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, podname, metav1.GetOptions{})
+	if err != nil {
+		return "", "", err
+	}
+	for _, e := range terminationEntries(pod, container) {
+		timeline.add(e)
+	}
+	return events.ResourceVersion, pod.ResourceVersion, nil
+}
+
+// watchTimeline add the new events and terminations of the container to the timeline until ctx is done
+func watchTimeline(ctx context.Context, timeline *logTimeline, namespace, podname, container, eventsVersion, podVersion string) error {
+	clientset := getClientSet()
+	events, err := watchtools.NewRetryWatcher(eventsVersion, &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = podEventsSelector(namespace, podname)
+			return clientset.CoreV1().Events(namespace).Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer events.Stop()
+	pods, err := watchtools.NewRetryWatcher(podVersion, &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = "metadata.name=" + podname
+			return clientset.CoreV1().Pods(namespace).Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer pods.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events.ResultChan():
+			if !ok {
+				return fmt.Errorf("the watch of the events is closed")
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if ev, ok := event.Object.(*v1.Event); ok {
+					if e, ok := eventEntry(ev, container); ok {
+						timeline.add(e)
+					}
+				}
+			case watch.Error:
+				return k8serror.FromObject(event.Object)
+			}
+		case event, ok := <-pods.ResultChan():
+			if !ok {
+				return fmt.Errorf("the watch of the pod is closed")
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if pod, ok := event.Object.(*v1.Pod); ok {
+					for _, e := range terminationEntries(pod, container) {
+						timeline.add(e)
+					}
+				}
+			case watch.Error:
+				return k8serror.FromObject(event.Object)
+			}
+		}
+	}
+}
+
+// PrintLogsWithEvents print container's logs to stdout like PrintLogs, interleaved by time with the events of the pod
+// and the terminations of the container. When opts.Follow the new events are watched too.
+func PrintLogsWithEvents(namespace, podname string, opts *v1.PodLogOptions, render *logRenderOptions, reconnect bool) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var logOut io.Writer = os.Stdout
+	var renderer *logRenderer
+	if render != nil {
+		renderer = render.newWriter(os.Stdout)
+		logOut = renderer
+	}
+	timeline := newLogTimeline(logOut, os.Stdout, term.AllowsColorOutput(os.Stdout), opts.Timestamps)
+	// the log lines are placed among the events by their timestamps
+	opts = opts.DeepCopy()
+	opts.Timestamps = true
+
+	eventsVersion, podVersion, err := podTimeline(ctx, timeline, namespace, podname, opts.Container)
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	watchCtx, cancel := context.WithCancel(ctx)
+	if opts.Follow {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := watchTimeline(watchCtx, timeline, namespace, podname, opts.Container, eventsVersion, podVersion); err != nil && watchCtx.Err() == nil {
+				logNotice.Printfln("events of %s/%s are no longer followed: %v", namespace, podname, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(eventFlushInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-watchCtx.Done():
+					return
+				case <-ticker.C:
+					timeline.FlushEntries()
+				}
+			}
+		}()
+	}
+	err = followLogs(ctx, namespace, podname, opts, timeline, reconnect)
+	cancel()
+	wg.Wait()
+	if flushErr := timeline.Flush(); err == nil {
+		err = flushErr
+	}
+	if renderer != nil {
+		if flushErr := renderer.Flush(); err == nil {
+			err = flushErr
+		}
+	}
+	return err
+}
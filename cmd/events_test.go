@@ -0,0 +1,116 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLogTimeline(t *testing.T) {
+	var out bytes.Buffer
+	timeline := newLogTimeline(&out, &out, false, false)
+	at := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		assert.NoError(t, err)
+		return ts
+	}
+	timeline.add(timelineEntry{time: at("2023-05-01T10:00:05Z"), key: "b", text: "event Warning Unhealthy: probe failed"})
+	timeline.add(timelineEntry{time: at("2023-05-01T10:00:01Z"), key: "a", text: "event Normal Started: started"})
+	timeline.add(timelineEntry{time: at("2023-05-01T10:00:01Z"), key: "a", text: "event Normal Started: started"})
+	timeline.add(timelineEntry{time: at("2023-05-01T10:00:09Z"), key: "c", text: "container app terminated: OOMKilled, exit code 137"})
+
+	_, err := timeline.Write([]byte("2023-05-01T10:00:00.5Z first\n2023-05-01T10:00:02Z second\n  at x\n2023-05-01T10:00"))
+	assert.NoError(t, err)
+	_, err = timeline.Write([]byte(":06Z third"))
+	assert.NoError(t, err)
+	assert.NoError(t, timeline.Flush())
+	assert.Equal(t, `first
+>>> 2023-05-01T10:00:01Z event Normal Started: started
+second
+  at x
+>>> 2023-05-01T10:00:05Z event Warning Unhealthy: probe failed
+third
+>>> 2023-05-01T10:00:09Z container app terminated: OOMKilled, exit code 137
+`, out.String())
+
+	out.Reset()
+	timeline = newLogTimeline(&out, &out, false, true)
+	_, err = timeline.Write([]byte("2023-05-01T10:00:00Z kept\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-05-01T10:00:00Z kept\n", out.String())
+}
+
+func TestEventEntry(t *testing.T) {
+	last := metav1.NewTime(time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC))
+	ev := &v1.Event{
+		ObjectMeta:     metav1.ObjectMeta{UID: "1"},
+		InvolvedObject: v1.ObjectReference{FieldPath: "spec.containers{app}"},
+		Type:           v1.EventTypeWarning,
+		Reason:         "Unhealthy",
+		Message:        "Liveness probe failed\n",
+		Count:          3,
+		LastTimestamp:  last,
+	}
+	e, ok := eventEntry(ev, "app")
+	assert.True(t, ok)
+	assert.Equal(t, "event Warning Unhealthy: Liveness probe failed (x3)", e.text)
+	assert.Equal(t, last.Time, e.time)
+	assert.True(t, e.warning)
+
+	_, ok = eventEntry(ev, "istio-proxy")
+	assert.False(t, ok)
+
+	ev.InvolvedObject.FieldPath = ""
+	ev.Count = 1
+	ev.Type = v1.EventTypeNormal
+	ev.Reason, ev.Message = "Scheduled", "assigned to node-1"
+	e, ok = eventEntry(ev, "istio-proxy")
+	assert.True(t, ok)
+	assert.Equal(t, "event Normal Scheduled: assigned to node-1", e.text)
+	assert.False(t, e.warning)
+}
+
+func TestTerminationEntries(t *testing.T) {
+	finished := metav1.NewTime(time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC))
+	pod := &v1.Pod{Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{
+		{Name: "sidecar"},
+		{
+			Name: "app",
+			LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+				Reason: "OOMKilled", ExitCode: 137, FinishedAt: finished,
+			}},
+			State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+		},
+	}}}
+	entries := terminationEntries(pod, "app")
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "container app terminated: OOMKilled, exit code 137", entries[0].text)
+	assert.Equal(t, finished.Time, entries[0].time)
+	assert.True(t, entries[0].warning)
+	assert.Empty(t, terminationEntries(pod, "sidecar"))
+}
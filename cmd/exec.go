@@ -0,0 +1,79 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+type ExecCmd struct {
+	BaseCommand
+}
+
+func (cl *ExecCmd) Init() {
+	cl.command = &cobra.Command{
+		Use:   "exec",
+		Short: "Run a command with arguments in a container incluster.",
+		Long: "Run a command with arguments in a container incluster. A TTY is allocated when both stdin and stdout are terminals, " +
+			"piped stdin is forwarded to the command, and kconsole exits with the exit code of the command.",
+		Example: "  kconsole exec default/nginx-0/nginx -- ls -la /app\n  kconsole exec -n default --pod nginx-0 -- sh -c 'echo $HOSTNAME'\n  cat dump.sql | kconsole exec db/pg-0 -- psql\n  kconsole exec @api-prod",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cl.runExec(cmd, args)
+		},
+		// the exit code of the remote command is returned as an error, it is not a usage error
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cl.command.DisableFlagsInUseLine = true
+	addTargetFlags(cl.command)
+}
+
+// splitCommand split args into the target args before `--` and the command after it,
+// the command of a bookmark target is used when none is given.
+func (cl ExecCmd) splitCommand(cmd *cobra.Command, args []string) (targetArgs, command []string, err error) {
+	dash := cmd.ArgsLenAtDash()
+	if dash < 0 {
+		dash = len(args)
+	}
+	if dash > 1 {
+		return nil, nil, fmt.Errorf("expected at most one target before '--', got %v", args[:dash])
+	}
+	targetArgs, command = args[:dash], args[dash:]
+	if len(command) == 0 {
+		command = bookmarkCommand(targetArgs)
+	}
+	if len(command) == 0 {
+		return nil, nil, fmt.Errorf("missing command, usage: kconsole exec [ns/pod[/container]] -- cmd args...")
+	}
+	return targetArgs, command, nil
+}
+
+func (cl ExecCmd) runExec(cmd *cobra.Command, args []string) error {
+	targetArgs, command, err := cl.splitCommand(cmd, args)
+	if err != nil {
+		return err
+	}
+	podname, namespace, selectcontainer := selectTarget(cmd, targetArgs)
+	return RunPodCommand(namespace, podname, selectcontainer, command)
+}
@@ -0,0 +1,47 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecCmd_splitCommand(t *testing.T) {
+	execCmd := &ExecCmd{}
+	execCmd.Init()
+	cmd := execCmd.CobraCmd()
+
+	assert.NoError(t, cmd.ParseFlags([]string{"default/nginx-0", "--", "ls", "-la", "/app"}))
+	targetArgs, command, err := execCmd.splitCommand(cmd, cmd.Flags().Args())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"default/nginx-0"}, targetArgs)
+	assert.Equal(t, []string{"ls", "-la", "/app"}, command)
+
+	// without `--` there is no command to run
+	execCmd.Init()
+	cmd = execCmd.CobraCmd()
+	assert.NoError(t, cmd.ParseFlags([]string{"default/nginx-0"}))
+	_, _, err = execCmd.splitCommand(cmd, cmd.Flags().Args())
+	assert.Error(t, err)
+}
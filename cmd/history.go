@@ -0,0 +1,338 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"kconsole/config"
+	"kconsole/utils/errorx"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+const (
+	flagList  = "list"
+	flagPin   = "pin"
+	flagUnpin = "unpin"
+)
+
+// marks of the pods selected before in the pod picker
+const (
+	markPinned = "★"
+	markRecent = "↺"
+)
+
+// rerunCommands the commands a history entry can be re-run with, they need nothing but the target
+var rerunCommands = map[string]bool{"console": true, "log": true, "debug": true}
+
+type HistoryCmd struct {
+	BaseCommand
+}
+
+func (cl *HistoryCmd) Init() {
+	cl.command = &cobra.Command{
+		Use:   "history",
+		Short: "List and re-run the recently selected containers.",
+		Long: "List and re-run the recently selected containers. An entry is re-run with the command it was selected by " +
+			"when that command needs nothing but the target, otherwise with console. A pod that is gone is replaced by " +
+			"a live pod with the same prefix, e.g. a new pod of the same deployment. Pinned entries are kept first.",
+		Example: "  kconsole history\n  kconsole history 2\n  kconsole history --list\n  kconsole history 2 --pin\n  kconsole history --clear",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cl.runHistory(cmd, args)
+		},
+	}
+	cl.command.DisableFlagsInUseLine = true
+	cl.command.Flags().Bool(flagList, false, "list the history without re-running an entry")
+	cl.command.Flags().Bool(flagPin, false, "pin the entry as a favourite, kept first in the history and the pod picker")
+	cl.command.Flags().Bool(flagUnpin, false, "unpin the entry")
+	cl.command.Flags().Bool(flagClear, false, "remove the unpinned entries")
+}
+
+func (cl HistoryCmd) runHistory(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	list, err := flags.GetBool(flagList)
+	errorx.CheckError(err)
+	pin, err := flags.GetBool(flagPin)
+	errorx.CheckError(err)
+	unpin, err := flags.GetBool(flagUnpin)
+	errorx.CheckError(err)
+	clearHistory, err := flags.GetBool(flagClear)
+	errorx.CheckError(err)
+
+	if clearHistory {
+		if err := config.ClearHistory(); err != nil {
+			return err
+		}
+		fmt.Println("cleared the unpinned history~")
+		return nil
+	}
+	entries, err := config.LoadHistory()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("the history is empty, containers selected by console, exec, log, debug etc. are recorded")
+	}
+	if list {
+		printHistory(entries, time.Now())
+		return nil
+	}
+
+	var index int
+	if len(args) == 1 {
+		if index, err = strconv.Atoi(args[0]); err != nil || index < 1 || index > len(entries) {
+			return fmt.Errorf("invalid history entry %q, expected a number from 1 to %d", args[0], len(entries))
+		}
+		index--
+	} else if pin || unpin {
+		return fmt.Errorf("--%s and --%s need the number of the entry", flagPin, flagUnpin)
+	} else {
+		labels := make([]string, 0, len(entries))
+		for i, e := range entries {
+			labels = append(labels, historyLabel(i, e))
+		}
+		index = SelectIndexUI(labels, "select a recent container")
+	}
+
+	if pin || unpin {
+		if err := config.PinHistory(index, pin); err != nil {
+			return err
+		}
+		fmt.Printf("%s %s~\n", map[bool]string{true: "pinned", false: "unpinned"}[pin], historyTarget(entries[index]))
+		return nil
+	}
+	return rerunHistory(cmd, entries[index])
+}
+
+// historyTarget render the target of the entry as ns/pod-prefix*/container
+func historyTarget(e config.HistoryEntry) string {
+	pod := e.Pod
+	if e.PodPrefix != e.Pod {
+		pod = e.PodPrefix + "*"
+	}
+	return e.Namespace + "/" + pod + "/" + e.Container
+}
+
+// historyLabel render the entry as an item of the history menu
+func historyLabel(index int, e config.HistoryEntry) string {
+	mark := " "
+	if e.Pinned {
+		mark = markPinned
+	}
+	return fmt.Sprintf("%d %s %s [%s] %s", index+1, mark, historyTarget(e), e.Cluster, e.Command)
+}
+
+// printHistory print the entries as a table
+func printHistory(entries []config.HistoryEntry, now time.Time) {
+	data := pterm.TableData{{"#", "", "CLUSTER", "TARGET", "COMMAND", "USED", "LAST USED"}}
+	for i, e := range entries {
+		mark := ""
+		if e.Pinned {
+			mark = markPinned
+		}
+		data = append(data, []string{
+			strconv.Itoa(i + 1), mark, e.Cluster, historyTarget(e), e.Command,
+			strconv.Itoa(e.Count), duration.HumanDuration(now.Sub(e.LastUsed)) + " ago",
+		})
+	}
+	errorx.CheckError(pterm.DefaultTable.WithHasHeader().WithData(data).Render())
+}
+
+// rerunHistory run the command of the entry on the pod it selected, or on its replacement
+func rerunHistory(cmd *cobra.Command, e config.HistoryEntry) error {
+	if err := useCluster(e.Auth, e.Cluster); err != nil {
+		return err
+	}
+	pod, err := resolveHistoryPod(e, clusterPods)
+	if err != nil {
+		return err
+	}
+	sub, args, err := rerunTarget(cmd, e, pod)
+	if err != nil {
+		return err
+	}
+	return sub.RunE(sub, args)
+}
+
+// rerunTarget return the command re-running the entry on the pod and its arguments. The flags of the command
+// are parsed so the persistent flags of the root, e.g. --lines, are merged into them.
+func rerunTarget(cmd *cobra.Command, e config.HistoryEntry, pod string) (*cobra.Command, []string, error) {
+	command := e.Command
+	if !rerunCommands[command] {
+		command = "console"
+	}
+	sub, _, err := cmd.Root().Find([]string{command})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := sub.ParseFlags(nil); err != nil {
+		return nil, nil, err
+	}
+	return sub, []string{e.Namespace + "/" + pod + "/" + e.Container}, nil
+}
+
+// podSource fetch the pods of a namespace, clusterPods from the cluster in use
+type podSource struct {
+	get  func(namespace, name string) (*v1.Pod, error)
+	list func(namespace string) []v1.Pod
+}
+
+var clusterPods = podSource{
+	get: func(namespace, name string) (*v1.Pod, error) {
+		return getClientSet().CoreV1().Pods(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	},
+	list: func(namespace string) []v1.Pod {
+		return podList(namespace, podListOptions(Target{})).Items
+	},
+}
+
+// resolveHistoryPod return the pod of the entry if it still runs, otherwise a live pod with the same prefix, ready ones first
+func resolveHistoryPod(e config.HistoryEntry, pods podSource) (string, error) {
+	pod, err := pods.get(e.Namespace, e.Pod)
+	if err == nil && pod.DeletionTimestamp == nil {
+		return pod.Name, nil
+	}
+	if err != nil && !k8serror.IsNotFound(err) {
+		return "", err
+	}
+	var candidates []v1.Pod
+	for _, p := range pods.list(e.Namespace) {
+		if p.DeletionTimestamp == nil && podPrefix(&p) == e.PodPrefix {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("pod %s/%s is gone and no pod has its prefix %q", e.Namespace, e.Pod, e.PodPrefix)
+	}
+	if ready := pickReadyPod(candidates); ready != nil {
+		return ready.Name, nil
+	}
+	return candidates[0].Name, nil
+}
+
+// podPrefix return the name of the pod without the suffix generated for it, e.g. "api-" for the pod
+// "api-7d9f8b6c5-x2x9z" of a deployment. Pods with stable names, e.g. of a statefulset, are their name.
+func podPrefix(pod *v1.Pod) string {
+	if pod.GenerateName == "" || !strings.HasPrefix(pod.Name, pod.GenerateName) {
+		return pod.Name
+	}
+	prefix := pod.GenerateName
+	if hash := pod.Labels["pod-template-hash"]; hash != "" {
+		prefix = strings.TrimSuffix(prefix, hash+"-")
+	}
+	return prefix
+}
+
+// clusterHistory return the history of the current cluster, a broken history file is only warned about
+func clusterHistory() []config.HistoryEntry {
+	entries, err := config.LoadHistory()
+	if err != nil {
+		pterm.Warning.Printfln("failed to read the history: %v", err)
+		return nil
+	}
+	auth, cluster := config.GetKconsoleConfig().Auth, currentClusterName()
+	result := entries[:0]
+	for _, e := range entries {
+		if (e.Auth == "" || e.Auth == auth) && e.Cluster == cluster {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// recordHistory add the selected container to the history, failing to write it is only warned about
+func recordHistory(command string, pod *v1.Pod, container string) {
+	err := config.RecordHistory(config.HistoryEntry{
+		Auth:      config.GetKconsoleConfig().Auth,
+		Cluster:   currentClusterName(),
+		Namespace: pod.Namespace,
+		PodPrefix: podPrefix(pod),
+		Pod:       pod.Name,
+		Container: container,
+		Command:   command,
+		LastUsed:  time.Now(),
+	})
+	if err != nil {
+		pterm.Warning.Printfln("failed to record the history: %v", err)
+	}
+}
+
+// sortPodsByHistory move the pods matching the history to the top in the order of the history,
+// and return the mark of each of them by name
+func sortPodsByHistory(pods []v1.Pod, entries []config.HistoryEntry) ([]v1.Pod, map[string]string) {
+	marks := make(map[string]string)
+	sorted := make([]v1.Pod, 0, len(pods))
+	for _, e := range entries {
+		for i := range pods {
+			pod := &pods[i]
+			key := pod.Namespace + "/" + pod.Name
+			if _, ok := marks[key]; ok || pod.Namespace != e.Namespace || podPrefix(pod) != e.PodPrefix {
+				continue
+			}
+			marks[key] = markRecent
+			if e.Pinned {
+				marks[key] = markPinned
+			}
+			sorted = append(sorted, *pod)
+		}
+	}
+	for i := range pods {
+		if _, ok := marks[pods[i].Namespace+"/"+pods[i].Name]; !ok {
+			sorted = append(sorted, pods[i])
+		}
+	}
+	return sorted, marks
+}
+
+// sortContainersByHistory move the containers of the pod used before to the top in the order of the history
+func sortContainersByHistory(pod *v1.Pod, containers []ContainerInfo, entries []config.HistoryEntry) []ContainerInfo {
+	prefix := podPrefix(pod)
+	sorted := make([]ContainerInfo, 0, len(containers))
+	used := make(map[string]bool)
+	for _, e := range entries {
+		if e.Namespace != pod.Namespace || e.PodPrefix != prefix || used[e.Container] {
+			continue
+		}
+		for _, c := range containers {
+			if c.Name == e.Container {
+				used[c.Name] = true
+				sorted = append(sorted, c)
+			}
+		}
+	}
+	for _, c := range containers {
+		if !used[c.Name] {
+			sorted = append(sorted, c)
+		}
+	}
+	return sorted
+}
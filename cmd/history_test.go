@@ -0,0 +1,183 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"kconsole/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func historyPod(ns, name, generateName, hash string) v1.Pod {
+	pod := v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name, GenerateName: generateName}}
+	if hash != "" {
+		pod.Labels = map[string]string{"pod-template-hash": hash}
+	}
+	return pod
+}
+
+func TestPodPrefix(t *testing.T) {
+	deployPod := historyPod("default", "api-7d9f8b6c5-x2x9z", "api-7d9f8b6c5-", "7d9f8b6c5")
+	assert.Equal(t, "api-", podPrefix(&deployPod))
+	dsPod := historyPod("default", "agent-k8z2q", "agent-", "")
+	assert.Equal(t, "agent-", podPrefix(&dsPod))
+	stsPod := historyPod("default", "db-0", "", "")
+	assert.Equal(t, "db-0", podPrefix(&stsPod))
+	plainPod := historyPod("default", "nginx", "", "")
+	assert.Equal(t, "nginx", podPrefix(&plainPod))
+}
+
+func TestSortPodsByHistory(t *testing.T) {
+	pods := []v1.Pod{
+		historyPod("default", "web-5b6c7d8e9f-aaaaa", "web-5b6c7d8e9f-", "5b6c7d8e9f"),
+		historyPod("default", "api-7d9f8b6c5-x2x9z", "api-7d9f8b6c5-", "7d9f8b6c5"),
+		historyPod("prod", "api-6c8d9e7f4-q1w2e", "api-6c8d9e7f4-", "6c8d9e7f4"),
+		historyPod("default", "nginx", "", ""),
+	}
+	entries := []config.HistoryEntry{
+		{Namespace: "default", PodPrefix: "nginx", Pinned: true},
+		{Namespace: "prod", PodPrefix: "api-"},
+	}
+	sorted, marks := sortPodsByHistory(pods, entries)
+	names := make([]string, 0, len(sorted))
+	for _, pod := range sorted {
+		names = append(names, pod.Name)
+	}
+	assert.Equal(t, []string{"nginx", "api-6c8d9e7f4-q1w2e", "web-5b6c7d8e9f-aaaaa", "api-7d9f8b6c5-x2x9z"}, names)
+	assert.Equal(t, map[string]string{"default/nginx": markPinned, "prod/api-6c8d9e7f4-q1w2e": markRecent}, marks)
+}
+
+func TestSortContainersByHistory(t *testing.T) {
+	pod := historyPod("default", "api-7d9f8b6c5-x2x9z", "api-7d9f8b6c5-", "7d9f8b6c5")
+	containers := []ContainerInfo{{Name: "app"}, {Name: "istio-proxy"}, {Name: "migrate"}}
+	entries := []config.HistoryEntry{
+		{Namespace: "default", PodPrefix: "api-", Container: "migrate"},
+		{Namespace: "default", PodPrefix: "web-", Container: "app"},
+	}
+	sorted := sortContainersByHistory(&pod, containers, entries)
+	assert.Equal(t, []ContainerInfo{{Name: "migrate"}, {Name: "app"}, {Name: "istio-proxy"}}, sorted)
+}
+
+func TestAddHistoryEntry(t *testing.T) {
+	now := time.Now()
+	entries := []config.HistoryEntry{
+		{Cluster: "c", Namespace: "default", PodPrefix: "api-", Container: "app", Pinned: true, Count: 3, LastUsed: now.Add(-time.Hour)},
+		{Cluster: "c", Namespace: "default", PodPrefix: "web-", Container: "app", Count: 1, LastUsed: now.Add(-time.Minute)},
+	}
+	entries = config.AddHistoryEntry(entries, config.HistoryEntry{
+		Cluster: "c", Namespace: "default", PodPrefix: "api-", Pod: "api-7d9f8b6c5-abcde", Container: "app", LastUsed: now,
+	})
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "api-7d9f8b6c5-abcde", entries[0].Pod)
+	assert.True(t, entries[0].Pinned)
+	assert.Equal(t, 4, entries[0].Count)
+
+	// a bcs cluster id and a kube context of the same name are different clusters
+	entries = config.AddHistoryEntry(entries, config.HistoryEntry{
+		Auth: config.BcsAuth, Cluster: "c", Namespace: "default", PodPrefix: "web-", Container: "app", LastUsed: now.Add(-time.Second),
+	})
+	assert.Len(t, entries, 3)
+	assert.Equal(t, config.BcsAuth, entries[1].Auth)
+	assert.Equal(t, 1, entries[1].Count)
+
+	old := config.MaxHistory
+	config.MaxHistory = 1
+	defer func() { config.MaxHistory = old }()
+	entries = config.AddHistoryEntry(entries, config.HistoryEntry{Cluster: "c", Namespace: "default", PodPrefix: "db-", Container: "db", LastUsed: now})
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "api-", entries[0].PodPrefix)
+	assert.Equal(t, "db-", entries[1].PodPrefix)
+}
+
+func TestResolveHistoryPod(t *testing.T) {
+	entry := config.HistoryEntry{Namespace: "prod", PodPrefix: "api-", Pod: "api-7d9f8b6c5-old", Container: "app"}
+	notFound := k8serror.NewNotFound(v1.Resource("pods"), entry.Pod)
+	running := func(pod v1.Pod, ready bool) v1.Pod {
+		pod.Status.Phase = v1.PodRunning
+		pod.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "app", Ready: ready}}
+		pod.Spec.Containers = []v1.Container{{Name: "app"}}
+		return pod
+	}
+	live := []v1.Pod{
+		running(historyPod("prod", "web-5c6d7e8f9-aaaaa", "web-5c6d7e8f9-", "5c6d7e8f9"), true),
+		running(historyPod("prod", "api-6b8c9d0e1-starting", "api-6b8c9d0e1-", "6b8c9d0e1"), false),
+		running(historyPod("prod", "api-6b8c9d0e1-ready", "api-6b8c9d0e1-", "6b8c9d0e1"), true),
+	}
+	pods := podSource{
+		get:  func(namespace, name string) (*v1.Pod, error) { return nil, notFound },
+		list: func(namespace string) []v1.Pod { return live },
+	}
+
+	// the pod is gone, a ready pod with the same prefix replaces it
+	name, err := resolveHistoryPod(entry, pods)
+	assert.NoError(t, err)
+	assert.Equal(t, "api-6b8c9d0e1-ready", name)
+
+	// the pod still runs
+	pods.get = func(namespace, name string) (*v1.Pod, error) {
+		pod := historyPod(namespace, name, "api-7d9f8b6c5-", "7d9f8b6c5")
+		return &pod, nil
+	}
+	name, err = resolveHistoryPod(entry, pods)
+	assert.NoError(t, err)
+	assert.Equal(t, "api-7d9f8b6c5-old", name)
+
+	// other errors are not hidden by the fallback
+	pods.get = func(namespace, name string) (*v1.Pod, error) {
+		return nil, k8serror.NewForbidden(v1.Resource("pods"), name, nil)
+	}
+	_, err = resolveHistoryPod(entry, pods)
+	assert.True(t, k8serror.IsForbidden(err))
+
+	// no pod has the prefix
+	pods.get = func(namespace, name string) (*v1.Pod, error) { return nil, notFound }
+	pods.list = func(namespace string) []v1.Pod { return live[:1] }
+	_, err = resolveHistoryPod(entry, pods)
+	assert.Error(t, err)
+}
+
+func TestRerunTarget(t *testing.T) {
+	root := NewBaseCommand().CobraCmd()
+	history, _, err := root.Find([]string{"history"})
+	assert.NoError(t, err)
+
+	entry := config.HistoryEntry{Namespace: "prod", PodPrefix: "api-", Pod: "api-old", Container: "app", Command: "log"}
+	sub, args, err := rerunTarget(history, entry, "api-new")
+	assert.NoError(t, err)
+	assert.Equal(t, "log", sub.Name())
+	assert.Equal(t, []string{"prod/api-new/app"}, args)
+	// the persistent --lines of the root is merged into the flags of log
+	lines, err := sub.Flags().GetInt64(flagLines)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), lines)
+
+	// commands needing more than the target are re-run with console
+	entry.Command = "exec"
+	sub, _, err = rerunTarget(history, entry, "api-new")
+	assert.NoError(t, err)
+	assert.Equal(t, "console", sub.Name())
+}
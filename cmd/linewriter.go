@@ -0,0 +1,83 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+// lineWriter split the bytes written to it into lines and pass each complete line, with its newline, to onLine.
+// The line is only valid during the call.
+type lineWriter struct {
+	onLine func(line []byte) error
+	buf    []byte
+}
+
+func newLineWriter(onLine func(line []byte) error) *lineWriter {
+	return &lineWriter{onLine: onLine}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	start := 0
+	defer func() {
+		w.buf = append(w.buf[:0], w.buf[start:]...)
+	}()
+	for {
+		i := bytes.IndexByte(w.buf[start:], '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		end := start + i + 1
+		err := w.onLine(w.buf[start:end])
+		start = end
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Flush pass on the last line even if it is not terminated by a newline
+func (w *lineWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := w.buf
+	w.buf = nil
+	return w.onLine(line)
+}
+
+// splitTimestamp split the RFC3339 timestamp kubelet puts in front of the lines of a log read with timestamps
+// from the rest of the line
+func splitTimestamp(line string) (t time.Time, stamp, rest string, ok bool) {
+	i := strings.IndexByte(line, ' ')
+	if i <= 0 {
+		return t, "", line, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, line[:i])
+	if err != nil {
+		return t, "", line, false
+	}
+	return t, line[:i], line[i+1:], true
+}
@@ -0,0 +1,66 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	w := newLineWriter(func(line []byte) error {
+		lines = append(lines, string(line))
+		return nil
+	})
+	for _, chunk := range []string{"fir", "st\nsecond\nth", "ird\n", "last"} {
+		n, err := w.Write([]byte(chunk))
+		assert.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+	assert.Equal(t, []string{"first\n", "second\n", "third\n"}, lines)
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, []string{"first\n", "second\n", "third\n", "last"}, lines)
+	// nothing is left to flush
+	assert.NoError(t, w.Flush())
+	assert.Len(t, lines, 4)
+
+	broken := errors.New("broken")
+	w = newLineWriter(func(line []byte) error { return broken })
+	_, err := w.Write([]byte("a\nb"))
+	assert.Equal(t, broken, err)
+}
+
+func TestSplitTimestamp(t *testing.T) {
+	ts, stamp, rest, ok := splitTimestamp("2023-05-01T10:00:00.123456789Z hello world")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2023, 5, 1, 10, 0, 0, 123456789, time.UTC), ts)
+	assert.Equal(t, "2023-05-01T10:00:00.123456789Z", stamp)
+	assert.Equal(t, "hello world", rest)
+
+	_, _, rest, ok = splitTimestamp("hello world")
+	assert.False(t, ok)
+	assert.Equal(t, "hello world", rest)
+}
@@ -22,10 +22,23 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"kconsole/utils/errorx"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	flagGzip     = "gzip"
+	flagAppend   = "append"
+	flagMaxSize  = "max-size"
+	flagMaxFiles = "max-files"
+	flagArchive  = "archive"
 )
 
 type LogDownCmd struct {
@@ -36,12 +49,32 @@ func (cl *LogDownCmd) Init() {
 	cl.command = &cobra.Command{
 		Use:   "logdown",
 		Short: "download pod's log for a container incluster.",
-		Long:  "download pod's log for a container incluster. Only the latest 150 lines.",
+		Long: "download pod's log for a container incluster. The whole log unless --lines is given, streamed to the file " +
+			"which is overwritten unless --append. With --follow the log keeps being written until Ctrl-C, and --max-size " +
+			"rotates the file to FILE.1, FILE.2... keeping --max-files of them. With --archive the current and previous logs " +
+			"of all containers of a namespace, or of the pods named by a selector, workload or service, are written to FILE " +
+			"as a tar.gz of ns/pod/container[.previous].log files with a manifest.json.",
+		Example: "  kconsole logdown nginx.log\n  kconsole logdown nginx.log default/nginx-0/nginx\n  kconsole logdown nginx.log -n default --pod nginx-0 -c nginx\n" +
+			"  kconsole logdown crash.log --previous default/nginx-0/nginx\n  kconsole logdown nginx.log.gz --gzip --lines 1000\n" +
+			"  kconsole logdown nginx.log -f --max-size 100Mi --max-files 3 default/nginx-0/nginx\n" +
+			"  kconsole logdown incident.tar.gz --archive -n prod -w deploy/api --since 1h",
+		Args: cobra.MaximumNArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return cl.runLogDown(cmd, args)
 		},
 	}
 	cl.command.DisableFlagsInUseLine = true
+	addTargetFlags(cl.command)
+	addLogFlags(cl.command)
+	flags := cl.command.Flags()
+	flags.Bool(flagGzip, false, "gzip the file")
+	flags.Bool(flagAppend, false, "append to the file instead of overwriting it")
+	flags.BoolP(flagFollow, "f", false, "keep writing the log as it is written until Ctrl-C")
+	flags.Bool(flagReconnect, false, "with --follow, reconnect when the container restarts")
+	flags.String(flagMaxSize, "", "rotate the file when it holds this much log, e.g. 100Mi, never if not set")
+	flags.Int(flagMaxFiles, 5, "number of rotated files to keep")
+	flags.Bool(flagArchive, false, "write the logs of all matching pods and containers to FILE as a tar.gz")
+	flags.Int(flagParallel, 5, "with --archive, maximum number of logs downloaded at the same time")
 }
 
 func (cl LogDownCmd) validateArgs(args []string) (downFilename string) {
@@ -52,12 +85,127 @@ func (cl LogDownCmd) validateArgs(args []string) (downFilename string) {
 	return
 }
 
+// maxSize parse --max-size, 0 if it is not set
+func (cl LogDownCmd) maxSize(cmd *cobra.Command) (int64, error) {
+	val, err := cmd.Flags().GetString(flagMaxSize)
+	if err != nil || val == "" {
+		return 0, err
+	}
+	q, err := resource.ParseQuantity(val)
+	if err != nil || q.Value() <= 0 {
+		return 0, fmt.Errorf("invalid --%s %q, expected a size like 100Mi or 1G", flagMaxSize, val)
+	}
+	return q.Value(), nil
+}
+
 func (cl LogDownCmd) runLogDown(cmd *cobra.Command, args []string) error {
 	// validate args logfilename
-	// call utils get pods
 	downFilename := cl.validateArgs(args)
-	podname, namespace, selectcontainer := SelectContainer()
+	flags := cmd.Flags()
+	compress, err := flags.GetBool(flagGzip)
+	errorx.CheckError(err)
+	appendMode, err := flags.GetBool(flagAppend)
+	errorx.CheckError(err)
+	follow, err := flags.GetBool(flagFollow)
+	errorx.CheckError(err)
+	reconnect, err := flags.GetBool(flagReconnect)
+	errorx.CheckError(err)
+	if reconnect && !follow {
+		return fmt.Errorf("--%s can only be used with --%s", flagReconnect, flagFollow)
+	}
+	maxSize, err := cl.maxSize(cmd)
+	if err != nil {
+		return err
+	}
+	maxFiles, err := flags.GetInt(flagMaxFiles)
+	errorx.CheckError(err)
+	if maxFiles < 0 {
+		return fmt.Errorf("--%s must not be negative", flagMaxFiles)
+	}
+	var lines int64 = -1
+	if flags.Changed(flagLines) {
+		lines, err = flags.GetInt64(flagLines)
+		errorx.CheckError(err)
+	}
+	archive, err := flags.GetBool(flagArchive)
+	errorx.CheckError(err)
+	if archive {
+		if follow || appendMode || maxSize > 0 {
+			return fmt.Errorf("--%s can not be used with --%s, --%s or --%s", flagArchive, flagFollow, flagAppend, flagMaxSize)
+		}
+		return cl.runArchive(cmd, downFilename, args[1:], lines)
+	}
+
+	// call utils get pods
+	podname, namespace, selectcontainer := selectTarget(cmd, args[1:])
+	opts, err := logOptionsFromFlags(cmd, selectcontainer, lines)
+	if err != nil {
+		return err
+	}
+	opts.Follow = follow
+	file, err := openLogFile(downFilename, appendMode, compress, maxSize, maxFiles)
+	if err != nil {
+		return err
+	}
 	// build exec real command
-	err := SaveLogs(namespace, podname, selectcontainer, downFilename)
-	return err
+	return SaveLogs(namespace, podname, opts, file, reconnect)
+}
+
+// runArchive write the logs of the target's pods to a tar.gz
+func (cl LogDownCmd) runArchive(cmd *cobra.Command, filename string, args []string, lines int64) error {
+	parallel, err := cmd.Flags().GetInt(flagParallel)
+	errorx.CheckError(err)
+	if parallel < 1 {
+		return fmt.Errorf("--%s must be at least 1", flagParallel)
+	}
+	target, err := targetFromFlags(cmd, args)
+	errorx.CheckErrorWithCode(err, errorx.ErrorArgsErr)
+	opts, err := logOptionsFromFlags(cmd, "", lines)
+	if err != nil {
+		return err
+	}
+	if opts.Previous {
+		return fmt.Errorf("--%s can not be used with --%s, the previous logs are always archived", flagPrevious, flagArchive)
+	}
+	pods, err := archivePods(target)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	archiver := &logArchiver{
+		opts:       opts,
+		container:  target.Container,
+		timestamps: opts.Timestamps,
+		parallel:   parallel,
+		fetch:      getLog,
+	}
+	manifest, err := archiver.Archive(ctx, currentClusterName(), pods, f)
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	logs, failed := 0, 0
+	for _, pod := range manifest.Pods {
+		for _, c := range pod.Containers {
+			for _, l := range c.Logs {
+				if l.Error != "" {
+					failed++
+					logNotice.Printfln("%s: %s", l.File, l.Error)
+				} else {
+					logs++
+				}
+			}
+		}
+	}
+	fmt.Printf("archived %d logs of %d pods to %s, %d failed~\n", logs, len(manifest.Pods), filename, failed)
+	return nil
 }
@@ -0,0 +1,169 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// logFile write logs to a file, gzip compressed if asked. When the file holds maxSize bytes of logs it is
+// rotated at the next line boundary: app.log becomes app.log.1, app.log.1 becomes app.log.2 and so on,
+// keeping maxFiles rotated files.
+type logFile struct {
+	path     string
+	compress bool
+	maxSize  int64
+	maxFiles int
+
+	file *os.File
+	gz   *gzip.Writer
+	// size the bytes written to the file, compressed ones when compressing, plus its size when appending.
+	// A compressed file may grow past maxSize by what gzip still buffers.
+	size        int64
+	atLineStart bool
+}
+
+// openLogFile open the log file, appending to it or truncating it
+func openLogFile(path string, appendMode, compress bool, maxSize int64, maxFiles int) (*logFile, error) {
+	f := &logFile{path: path, compress: compress, maxSize: maxSize, maxFiles: maxFiles, atLineStart: true}
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appendMode {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	if err := f.open(flag); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *logFile) open(flag int) error {
+	file, err := os.OpenFile(f.path, flag, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	if f.compress {
+		// appending to a gzip file adds a member, gzip tools read all members as one stream
+		f.gz = gzip.NewWriter(&countingWriter{out: file, count: &f.size})
+	}
+	return nil
+}
+
+func (f *logFile) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if f.maxSize > 0 && f.size >= f.maxSize && f.atLineStart {
+			if err := f.rotate(); err != nil {
+				return written, err
+			}
+		}
+		chunk := p
+		if f.maxSize > 0 {
+			if i := bytes.IndexByte(p, '\n'); i >= 0 {
+				chunk = p[:i+1]
+			}
+		}
+		var err error
+		var n int
+		if f.gz != nil {
+			n, err = f.gz.Write(chunk)
+		} else {
+			n, err = f.file.Write(chunk)
+		}
+		written += n
+		if f.gz == nil {
+			f.size += int64(n)
+		}
+		if err != nil {
+			return written, err
+		}
+		f.atLineStart = chunk[len(chunk)-1] == '\n'
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
+
+// countingWriter add the bytes written to out to count
+type countingWriter struct {
+	out   io.Writer
+	count *int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.out.Write(p)
+	*w.count += int64(n)
+	return n, err
+}
+
+// rotatedName return the name of the n-th rotated file, e.g. app.log.1 or app.log.1.gz
+func (f *logFile) rotatedName(n int) string {
+	if f.compress && strings.HasSuffix(f.path, ".gz") {
+		return fmt.Sprintf("%s.%d.gz", strings.TrimSuffix(f.path, ".gz"), n)
+	}
+	return fmt.Sprintf("%s.%d", f.path, n)
+}
+
+// rotate close the file, shift the rotated files by one dropping the oldest, and start a new file
+func (f *logFile) rotate() error {
+	if err := f.closeFile(); err != nil {
+		return err
+	}
+	if f.maxFiles > 0 {
+		if err := os.Remove(f.rotatedName(f.maxFiles)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for n := f.maxFiles - 1; n >= 1; n-- {
+			if err := os.Rename(f.rotatedName(n), f.rotatedName(n+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(f.path, f.rotatedName(1)); err != nil {
+			return err
+		}
+	}
+	return f.open(os.O_WRONLY | os.O_CREATE | os.O_TRUNC)
+}
+
+func (f *logFile) closeFile() error {
+	if f.gz != nil {
+		if err := f.gz.Close(); err != nil {
+			f.file.Close()
+			return err
+		}
+	}
+	return f.file.Close()
+}
+
+// Close flush the compressed stream and close the file
+func (f *logFile) Close() error {
+	return f.closeFile()
+}
@@ -0,0 +1,134 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"compress/gzip"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeLogFile(t *testing.T, path string, appendMode, compress bool, maxSize int64, maxFiles int, data ...string) {
+	f, err := openLogFile(path, appendMode, compress, maxSize, maxFiles)
+	assert.NoError(t, err)
+	for _, d := range data {
+		_, err = f.Write([]byte(d))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, f.Close())
+}
+
+func readFile(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestLogFileTruncateAndAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeLogFile(t, path, false, false, 0, 0, "a long first download\n")
+	writeLogFile(t, path, false, false, 0, 0, "short\n")
+	assert.Equal(t, "short\n", readFile(t, path))
+
+	writeLogFile(t, path, true, false, 0, 0, "more\n")
+	assert.Equal(t, "short\nmore\n", readFile(t, path))
+}
+
+func TestLogFileGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	writeLogFile(t, path, false, true, 0, 0, "line 1\n")
+	writeLogFile(t, path, true, true, 0, 0, "line 2\n")
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(gz)
+	assert.NoError(t, err)
+	assert.Equal(t, "line 1\nline 2\n", string(data))
+}
+
+func TestLogFileRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	// lines are never split across files, the oldest file is dropped
+	writeLogFile(t, path, false, false, 10, 2, "zzzzzzzzzz\n", "0123456", "789\nabc\n", "def\n", "ghijklmnop\n", "q\n")
+	assert.Equal(t, "q\n", readFile(t, path))
+	assert.Equal(t, "abc\ndef\nghijklmnop\n", readFile(t, path+".1"))
+	assert.Equal(t, "0123456789\n", readFile(t, path+".2"))
+	assert.NoFileExists(t, path+".3")
+
+	gzPath := filepath.Join(t.TempDir(), "app.log.gz")
+	f := &logFile{path: gzPath, compress: true}
+	assert.Equal(t, filepath.Join(filepath.Dir(gzPath), "app.log.1.gz"), f.rotatedName(1))
+}
+
+func readGzipFile(t *testing.T, path string) string {
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(gz)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestLogFileRotateGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	const maxSize = 16 << 10
+	// random lines hardly compress, so the log is several times maxSize once compressed
+	rnd := rand.New(rand.NewSource(1))
+	var lines []string
+	for i := 0; i < 1000; i++ {
+		b := make([]byte, 64)
+		rnd.Read(b)
+		lines = append(lines, hex.EncodeToString(b)+"\n")
+	}
+	writeLogFile(t, path, false, true, maxSize, 10, lines...)
+
+	// the rotated files hold at least maxSize compressed bytes, not maxSize bytes of log
+	var content string
+	rotated := 0
+	for n := 10; n >= 1; n-- {
+		name := filepath.Join(filepath.Dir(path), fmt.Sprintf("app.log.%d.gz", n))
+		info, err := os.Stat(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, info.Size(), int64(maxSize))
+		rotated++
+		content += readGzipFile(t, name)
+	}
+	assert.Greater(t, rotated, 0)
+	content += readGzipFile(t, path)
+	assert.Equal(t, strings.Join(lines, ""), content)
+}
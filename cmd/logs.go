@@ -0,0 +1,224 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	flagSince      = "since"
+	flagSinceTime  = "since-time"
+	flagTimestamps = "timestamps"
+	flagPrevious   = "previous"
+	flagLimitBytes = "limit-bytes"
+)
+
+// logReconnectInterval the interval of checking whether the container runs again before reconnecting
+const logReconnectInterval = 2 * time.Second
+
+// logNotice print a notice about the log stream to stderr, keeping stdout for the logs
+var logNotice = pterm.Info.WithWriter(os.Stderr)
+
+// addLogFlags register the flags selecting which part of the log is read
+func addLogFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.Duration(flagSince, 0, "only the log newer than a relative duration, e.g. 10m or 2h, --lines is ignored unless set")
+	flags.String(flagSinceTime, "", "only the log after a time in RFC3339, e.g. 2024-01-02T15:04:05Z, --lines is ignored unless set")
+	flags.Bool(flagTimestamps, false, "prefix every line with its RFC3339 timestamp")
+	flags.BoolP(flagPrevious, "p", false, "the log of the previous instance of the container, e.g. the one that crashed")
+	flags.Int64(flagLimitBytes, 0, "maximum bytes of the log to read, no limit if 0")
+}
+
+// logOptionsFromFlags build the log options of the container from the log flags. The last lines are read,
+// all of them if lines is -1, unless a time window is given and --lines is not.
+func logOptionsFromFlags(cmd *cobra.Command, container string, lines int64) (*v1.PodLogOptions, error) {
+	flags := cmd.Flags()
+	since, err := flags.GetDuration(flagSince)
+	if err != nil {
+		return nil, err
+	}
+	sinceTime, err := flags.GetString(flagSinceTime)
+	if err != nil {
+		return nil, err
+	}
+	if since < 0 {
+		return nil, fmt.Errorf("--%s must be positive", flagSince)
+	}
+	if since > 0 && sinceTime != "" {
+		return nil, fmt.Errorf("--%s and --%s can not be used together", flagSince, flagSinceTime)
+	}
+	if (since > 0 || sinceTime != "") && !flags.Changed(flagLines) {
+		lines = -1
+	}
+	opts := newLogOptions(container, lines)
+	if since > 0 {
+		seconds := int64(math.Ceil(since.Seconds()))
+		opts.SinceSeconds = &seconds
+	}
+	if sinceTime != "" {
+		t, err := time.Parse(time.RFC3339, sinceTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s %q, expected RFC3339 e.g. 2024-01-02T15:04:05Z", flagSinceTime, sinceTime)
+		}
+		opts.SinceTime = &metav1.Time{Time: t}
+	}
+	if opts.Timestamps, err = flags.GetBool(flagTimestamps); err != nil {
+		return nil, err
+	}
+	if opts.Previous, err = flags.GetBool(flagPrevious); err != nil {
+		return nil, err
+	}
+	limitBytes, err := flags.GetInt64(flagLimitBytes)
+	if err != nil {
+		return nil, err
+	}
+	if limitBytes < 0 {
+		return nil, fmt.Errorf("--%s must be positive", flagLimitBytes)
+	}
+	if limitBytes > 0 {
+		opts.LimitBytes = &limitBytes
+	}
+	return opts, nil
+}
+
+// streamLogs copy the container's logs to out as they arrive until the stream ends or ctx is done
+func streamLogs(ctx context.Context, namespace, podname string, opts *v1.PodLogOptions, out io.Writer) error {
+	lr, err := getLog(ctx, namespace, podname, opts)
+	if err != nil {
+		return err
+	}
+	defer lr.Close()
+	_, err = io.Copy(out, lr)
+	return err
+}
+
+// followLogs stream the container's logs to out. Interrupting by ctx is not an error. With reconnect and
+// opts.Follow, a stream that ends, e.g. because the container restarted, is reopened from the timestamp of
+// the last line written once the container runs again.
+func followLogs(ctx context.Context, namespace, podname string, opts *v1.PodLogOptions, out io.Writer, reconnect bool) error {
+	if !opts.Follow || !reconnect {
+		err := streamLogs(ctx, namespace, podname, opts, out)
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	// the logs are read with timestamps to know where to resume, they are stripped unless asked for
+	w := newResumeWriter(out, opts.Timestamps)
+	opts = opts.DeepCopy()
+	opts.Timestamps = true
+	for {
+		err := streamLogs(ctx, namespace, podname, opts, w)
+		if ctx.Err() != nil {
+			return w.Flush()
+		}
+		if err != nil {
+			logNotice.Printfln("log stream of %s/%s/%s broke: %v, reconnecting", namespace, podname, opts.Container, err)
+		} else {
+			logNotice.Printfln("log stream of %s/%s/%s ended, reconnecting when the container runs", namespace, podname, opts.Container)
+		}
+		if err := waitContainerRunning(ctx, namespace, podname, opts.Container); err != nil {
+			if ctx.Err() != nil {
+				err = nil
+			}
+			if flushErr := w.Flush(); err == nil {
+				err = flushErr
+			}
+			return err
+		}
+		opts = w.resumeOptions(opts)
+	}
+}
+
+// resumeWriter write the lines of the log streams read with timestamps to out, remembering the timestamp of
+// the last line so that a reopened stream skips the lines already written
+type resumeWriter struct {
+	*lineWriter
+	out        io.Writer
+	keepStamps bool
+	last       time.Time
+	resume     time.Time
+}
+
+func newResumeWriter(out io.Writer, keepStamps bool) *resumeWriter {
+	w := &resumeWriter{out: out, keepStamps: keepStamps}
+	w.lineWriter = newLineWriter(w.writeLine)
+	return w
+}
+
+func (w *resumeWriter) writeLine(line []byte) error {
+	if t, _, rest, ok := splitTimestamp(string(line)); ok {
+		// SinceTime only has a precision of seconds, the reopened stream repeats the lines up to the last one
+		if !t.After(w.resume) {
+			return nil
+		}
+		w.last = t
+		if !w.keepStamps {
+			line = line[len(line)-len(rest):]
+		}
+	}
+	_, err := w.out.Write(line)
+	return err
+}
+
+// resumeOptions return the options reopening the stream after the last line written, opts unchanged if no
+// line has been written yet. The unterminated line of a broken stream is dropped, the reopened stream reads
+// it again.
+func (w *resumeWriter) resumeOptions(opts *v1.PodLogOptions) *v1.PodLogOptions {
+	w.buf = w.buf[:0]
+	if w.last.IsZero() {
+		return opts
+	}
+	w.resume = w.last
+	reopen := opts.DeepCopy()
+	reopen.TailLines = nil
+	reopen.SinceSeconds = nil
+	reopen.SinceTime = &metav1.Time{Time: w.last}
+	return reopen
+}
+
+// waitContainerRunning wait until the container of the pod runs, failing if the pod is gone
+func waitContainerRunning(ctx context.Context, namespace, podname, container string) error {
+	return wait.PollUntilContextCancel(ctx, logReconnectInterval, true, func(ctx context.Context) (bool, error) {
+		pod, err := getClientSet().CoreV1().Pods(namespace).Get(ctx, podname, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("can not reconnect to %s/%s: %v", namespace, podname, err)
+		}
+		if pod.DeletionTimestamp != nil {
+			return false, fmt.Errorf("can not reconnect to %s/%s: the pod is terminating", namespace, podname)
+		}
+		status := findContainerStatus(pod.Status.ContainerStatuses, container)
+		return status != nil && status.State.Running != nil, nil
+	})
+}
@@ -0,0 +1,108 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLogFlagsCmd(args ...string) (*cobra.Command, error) {
+	cmd := &cobra.Command{}
+	cmd.Flags().Int64(flagLines, 150, "")
+	addLogFlags(cmd)
+	return cmd, cmd.ParseFlags(args)
+}
+
+func TestLogOptionsFromFlags(t *testing.T) {
+	cmd, err := newLogFlagsCmd("--previous", "--timestamps", "--limit-bytes", "1024")
+	assert.NoError(t, err)
+	opts, err := logOptionsFromFlags(cmd, "app", 150)
+	assert.NoError(t, err)
+	assert.Equal(t, "app", opts.Container)
+	assert.Equal(t, int64(150), *opts.TailLines)
+	assert.True(t, opts.Previous)
+	assert.True(t, opts.Timestamps)
+	assert.Equal(t, int64(1024), *opts.LimitBytes)
+
+	// a time window reads all lines unless --lines is set
+	cmd, err = newLogFlagsCmd("--since", "90s")
+	assert.NoError(t, err)
+	opts, err = logOptionsFromFlags(cmd, "app", 150)
+	assert.NoError(t, err)
+	assert.Nil(t, opts.TailLines)
+	assert.Equal(t, int64(90), *opts.SinceSeconds)
+
+	cmd, err = newLogFlagsCmd("--since-time", "2024-01-02T15:04:05Z", "--lines", "10")
+	assert.NoError(t, err)
+	opts, err = logOptionsFromFlags(cmd, "app", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), *opts.TailLines)
+	assert.True(t, opts.SinceTime.Time.Equal(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)))
+
+	for _, args := range [][]string{
+		{"--since", "10m", "--since-time", "2024-01-02T15:04:05Z"},
+		{"--since-time", "yesterday"},
+		{"--limit-bytes", "-1"},
+	} {
+		cmd, err = newLogFlagsCmd(args...)
+		assert.NoError(t, err)
+		_, err = logOptionsFromFlags(cmd, "app", 150)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestResumeWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := newResumeWriter(&out, false)
+	opts := newLogOptions("app", 150)
+	opts.Timestamps = true
+
+	// nothing written yet, the stream is reopened as it was
+	assert.Same(t, opts, w.resumeOptions(opts))
+
+	_, err := w.Write([]byte("2024-01-02T15:04:05.100Z first\n2024-01-02T15:04:05.200Z second\n2024-01-02T15:04:05.300Z thi"))
+	assert.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", out.String())
+
+	reopen := w.resumeOptions(opts)
+	assert.Nil(t, reopen.TailLines)
+	assert.True(t, reopen.SinceTime.Time.Equal(time.Date(2024, 1, 2, 15, 4, 5, 200000000, time.UTC)))
+	assert.Equal(t, int64(150), *opts.TailLines)
+
+	// the reopened stream starts at the second, the lines already written are skipped and the broken one is read again
+	out.Reset()
+	_, err = w.Write([]byte("2024-01-02T15:04:05.100Z first\n2024-01-02T15:04:05.200Z second\n2024-01-02T15:04:05.300Z third\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "third\n", out.String())
+
+	// the timestamps asked for are kept
+	out.Reset()
+	w = newResumeWriter(&out, true)
+	_, err = w.Write([]byte("2024-01-02T15:04:05.100Z first\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-01-02T15:04:05.100Z first\n", out.String())
+}
@@ -0,0 +1,88 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"fmt"
+	"kconsole/config"
+	"kconsole/utils/errorx"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagClear      = "clear"
+	flagAccessible = "accessible"
+)
+
+type NamespaceCmd struct {
+	BaseCommand
+}
+
+func (cl *NamespaceCmd) Init() {
+	cl.command = &cobra.Command{
+		Use:     "namespace",
+		Aliases: []string{"ns"},
+		Short:   "Set the default namespace of the current cluster.",
+		Long: "Set the default namespace of the current cluster. Pods are listed in the default namespace " +
+			"unless --namespace or --all-namespaces is given. Without a name the namespace is selected from a menu.",
+		Example: "  kconsole namespace prod\n  kconsole namespace\n  kconsole namespace --clear\n  kconsole namespace --accessible dev,test",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cl.runNamespace(cmd, args)
+		},
+	}
+	cl.command.DisableFlagsInUseLine = true
+	cl.command.Flags().Bool(flagClear, false, "clear the default namespace, list pods in all namespaces")
+	cl.command.Flags().StringSlice(flagAccessible, nil, "namespaces you can access, listed when listing pods of all namespaces is forbidden")
+}
+
+func (cl NamespaceCmd) runNamespace(cmd *cobra.Command, args []string) error {
+	clearNamespace, err := cmd.Flags().GetBool(flagClear)
+	errorx.CheckError(err)
+	cluster := currentClusterName()
+	cc := config.GetKconsoleConfig().GetClusterConfig(cluster)
+	if cmd.Flags().Changed(flagAccessible) {
+		cc.Namespaces, err = cmd.Flags().GetStringSlice(flagAccessible)
+		errorx.CheckError(err)
+		config.SetClusterConfig(cc)
+		fmt.Printf("accessible namespaces of cluster %s: %v~\n", cluster, cc.Namespaces)
+		if !clearNamespace && len(args) == 0 {
+			return nil
+		}
+	}
+	switch {
+	case clearNamespace:
+		cc.Namespace = ""
+	case len(args) == 1:
+		cc.Namespace = args[0]
+	default:
+		cc.Namespace = SelectUI(ListNamespaces(), "select the default namespace")
+	}
+	config.SetClusterConfig(cc)
+	if cc.Namespace == "" {
+		fmt.Printf("cleared the default namespace of cluster %s~\n", cluster)
+	} else {
+		fmt.Printf("default namespace of cluster %s: %s~\n", cluster, cc.Namespace)
+	}
+	return nil
+}
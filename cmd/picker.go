@@ -0,0 +1,238 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"fmt"
+	"kconsole/utils/errorx"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+	"github.com/pterm/pterm"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// podRow a pod rendered as a row of the pod picker
+type podRow struct {
+	Namespace string
+	Name      string
+	// Mark whether the pod is a favourite or was selected recently
+	Mark string
+	// Row the aligned and coloured columns of the pod
+	Row string
+	// Text the plain columns of the pod used for searching
+	Text   string
+	Labels string
+	Owner  string
+	IP     string
+	Node   string
+	Images string
+}
+
+var podColumns = []string{"NAMESPACE", "NAME", "STATUS", "READY", "RESTARTS", "AGE", "NODE"}
+
+// podStatus return the status of the pod the way kubectl shows it, e.g. Running, CrashLoopBackOff, Terminating
+func podStatus(pod *v1.Pod) string {
+	if pod.DeletionTimestamp != nil {
+		return "Terminating"
+	}
+	status := string(pod.Status.Phase)
+	if pod.Status.Reason != "" {
+		status = pod.Status.Reason
+	}
+	for _, c := range pod.Status.InitContainerStatuses {
+		if c.State.Terminated != nil && c.State.Terminated.ExitCode == 0 {
+			continue
+		}
+		if c.State.Waiting != nil && c.State.Waiting.Reason != "" && c.State.Waiting.Reason != "PodInitializing" {
+			return "Init:" + c.State.Waiting.Reason
+		}
+		if c.State.Terminated != nil && c.State.Terminated.Reason != "" {
+			return "Init:" + c.State.Terminated.Reason
+		}
+	}
+	for _, c := range pod.Status.ContainerStatuses {
+		if c.State.Waiting != nil && c.State.Waiting.Reason != "" {
+			return c.State.Waiting.Reason
+		}
+		if c.State.Terminated != nil && c.State.Terminated.Reason != "" {
+			status = c.State.Terminated.Reason
+		}
+	}
+	return status
+}
+
+// podReady return the number of ready containers and the number of containers
+func podReady(pod *v1.Pod) (ready, total int) {
+	for _, c := range pod.Status.ContainerStatuses {
+		if c.Ready {
+			ready++
+		}
+	}
+	return ready, len(pod.Spec.Containers)
+}
+
+// podRestarts return the restarts of all containers of the pod
+func podRestarts(pod *v1.Pod) (restarts int32) {
+	for _, c := range pod.Status.ContainerStatuses {
+		restarts += c.RestartCount
+	}
+	return
+}
+
+// podOwner return the workload owning the pod, a ReplicaSet created by a Deployment is shown as the Deployment
+func podOwner(pod *v1.Pod) string {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		if hash := pod.Labels["pod-template-hash"]; ref.Kind == "ReplicaSet" && strings.HasSuffix(ref.Name, "-"+hash) {
+			return "Deployment/" + strings.TrimSuffix(ref.Name, "-"+hash)
+		}
+		return ref.Kind + "/" + ref.Name
+	}
+	return "<none>"
+}
+
+// podHealthy report whether the pod is running with all containers ready, or has completed
+func podHealthy(pod *v1.Pod, status string) bool {
+	ready, total := podReady(pod)
+	return (status == string(v1.PodRunning) && ready == total) || status == string(v1.PodSucceeded) || status == "Completed"
+}
+
+// newPodRows render the pods as aligned rows with a header, unhealthy pods are coloured
+func newPodRows(pods []v1.Pod, now time.Time) (header string, rows []podRow) {
+	cells := make([][]string, 0, len(pods))
+	widths := make([]int, len(podColumns))
+	for i, column := range podColumns {
+		widths[i] = len(column)
+	}
+	for i := range pods {
+		pod := &pods[i]
+		ready, total := podReady(pod)
+		row := []string{
+			pod.Namespace,
+			pod.Name,
+			podStatus(pod),
+			fmt.Sprintf("%d/%d", ready, total),
+			strconv.Itoa(int(podRestarts(pod))),
+			duration.HumanDuration(now.Sub(pod.CreationTimestamp.Time)),
+			pod.Spec.NodeName,
+		}
+		for j, cell := range row {
+			if len(cell) > widths[j] {
+				widths[j] = len(cell)
+			}
+		}
+		cells = append(cells, row)
+	}
+
+	columns := make([]string, len(podColumns))
+	for i, column := range podColumns {
+		columns[i] = fmt.Sprintf("%-*s", widths[i], column)
+	}
+	header = strings.Join(columns, "  ")
+
+	rows = make([]podRow, 0, len(pods))
+	for i := range pods {
+		pod := &pods[i]
+		padded := make([]string, len(cells[i]))
+		for j, cell := range cells[i] {
+			padded[j] = fmt.Sprintf("%-*s", widths[j], cell)
+		}
+		text := strings.Join(padded, "  ")
+		status := cells[i][2]
+		switch {
+		case podHealthy(pod, status):
+		case status == string(v1.PodPending) || status == "ContainerCreating" || status == "PodInitializing":
+			padded[2] = pterm.FgYellow.Sprint(padded[2])
+		default:
+			padded[2] = pterm.FgRed.Sprint(padded[2])
+		}
+		if podRestarts(pod) > 0 {
+			padded[4] = pterm.FgYellow.Sprint(padded[4])
+		}
+
+		labels := make([]string, 0, len(pod.Labels))
+		for k, v := range pod.Labels {
+			labels = append(labels, k+"="+v)
+		}
+		sort.Strings(labels)
+		images := make([]string, 0, len(pod.Spec.Containers))
+		for _, c := range pod.Spec.Containers {
+			images = append(images, c.Image)
+		}
+		rows = append(rows, podRow{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			Row:       strings.Join(padded, "  "),
+			Text:      text,
+			Labels:    strings.Join(labels, ","),
+			Owner:     podOwner(pod),
+			IP:        pod.Status.PodIP,
+			Node:      pod.Spec.NodeName,
+			Images:    strings.Join(images, ","),
+		})
+	}
+	return header, rows
+}
+
+// selectPodUI select a pod from a list showing its status, readiness, restarts, age and node,
+// with the labels, owner, IP and images of the highlighted pod below the list.
+// marks are shown before the pods by namespace/name.
+func selectPodUI(pods []v1.Pod, marks map[string]string, title string) (pod, ns string) {
+	header, rows := newPodRows(pods, time.Now())
+	keys := make([]string, 0, len(rows))
+	items := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		row.Mark = " "
+		if mark, ok := marks[row.Namespace+"/"+row.Name]; ok {
+			row.Mark = mark
+		}
+		keys = append(keys, row.Text)
+		items = append(items, row)
+	}
+	prompt := promptui.Select{
+		Label: header,
+		Size:  10,
+		Templates: &promptui.SelectTemplates{
+			Help:     fmt.Sprintf(`{{ "?" | blue }} %s {{ "(arrow keys to navigate, / toggles search)" | faint }}`, title),
+			Label:    `      {{ . | bold }}`,
+			Active:   `▸ {{ .Item.Mark | yellow }} {{ .Item.Row }}`,
+			Inactive: `  {{ .Item.Mark | yellow }} {{ .Item.Row }}`,
+			Selected: `{{ "✔" | green }} {{ .Item.Namespace }}/{{ .Item.Name }}`,
+			Details: `
+{{ "Labels:" | faint }}	{{ .Item.Labels }}
+{{ "Owner:" | faint }}	{{ .Item.Owner }}
+{{ "IP:" | faint }}	{{ .Item.IP }}
+{{ "Node:" | faint }}	{{ .Item.Node }}
+{{ "Images:" | faint }}	{{ .Item.Images }}`,
+		},
+	}
+	index, err := runRankedSelect(prompt, items, keys)
+	errorx.CheckErrorWithCode(err, errorx.ErrorSelectExit)
+	return rows[index].Name, rows[index].Namespace
+}
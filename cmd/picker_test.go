@@ -0,0 +1,78 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodStatus(t *testing.T) {
+	pod := &v1.Pod{Status: v1.PodStatus{
+		Phase: v1.PodRunning,
+		ContainerStatuses: []v1.ContainerStatus{{
+			State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		}},
+	}}
+	assert.Equal(t, "CrashLoopBackOff", podStatus(pod))
+
+	pod = &v1.Pod{Status: v1.PodStatus{
+		Phase: v1.PodPending,
+		InitContainerStatuses: []v1.ContainerStatus{{
+			State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}},
+		}},
+	}}
+	assert.Equal(t, "Init:Error", podStatus(pod))
+}
+
+func TestNewPodRows(t *testing.T) {
+	now := time.Now()
+	controller := true
+	pods := []v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              "api-7d9f8b6c5-x2x9z",
+			CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour)),
+			Labels:            map[string]string{"app": "api", "pod-template-hash": "7d9f8b6c5"},
+			OwnerReferences:   []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "api-7d9f8b6c5", Controller: &controller}},
+		},
+		Spec: v1.PodSpec{NodeName: "node-1", Containers: []v1.Container{{Name: "api", Image: "api:v1"}}},
+		Status: v1.PodStatus{
+			Phase:             v1.PodRunning,
+			PodIP:             "10.0.0.1",
+			ContainerStatuses: []v1.ContainerStatus{{Name: "api", Ready: true}},
+		},
+	}}
+	header, rows := newPodRows(pods, now)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "Deployment/api", rows[0].Owner)
+	assert.Equal(t, "app=api,pod-template-hash=7d9f8b6c5", rows[0].Labels)
+	assert.Equal(t, "default    api-7d9f8b6c5-x2x9z  Running  1/1    0         120m  node-1", rows[0].Text)
+	// the columns of the header line up with the columns of the rows
+	assert.Equal(t, strings.Index(header, "STATUS"), strings.Index(rows[0].Text, "Running"))
+	assert.Equal(t, strings.Index(header, "NODE"), strings.Index(rows[0].Text, "node-1"))
+}
@@ -22,9 +22,26 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"kconsole/utils/errorx"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/term"
+)
+
+const (
+	flagFollow    = "follow"
+	flagReconnect = "reconnect"
+	flagAggregate = "aggregate"
+	flagRaw       = "raw"
+	flagFilter    = "filter"
+	flagFields    = "fields"
+	flagJq        = "jq"
+	flagView      = "view"
 )
 
 type LogCmd struct {
@@ -35,20 +52,111 @@ func (cl *LogCmd) Init() {
 	cl.command = &cobra.Command{
 		Use:   "log",
 		Short: "show pod's log for a container incluster.",
-		Long:  "show pod's log for a container incluster. Only the latest 150 lines.",
+		Long: "show pod's log for a container incluster. Only the latest 150 lines. With --follow the log is streamed " +
+			"until Ctrl-C, and with --reconnect it keeps following when the container restarts. With --aggregate the logs of " +
+			"all containers of all pods matching a selector, workload or service are followed together, including the pods started later. With --view the log is paged in a full-screen viewer: " +
+			"/ searches, n/N jump between matches, w toggles wrapping, t timestamps, f following and q quits. With --events the " +
+			"events of the pod and the terminations of the container, e.g. OOMKilled, are shown among the log lines by time.",
+		Example: "  kconsole log\n  kconsole log default/nginx-0/nginx\n  kconsole log -n default --pod nginx-0 -c nginx\n  kconsole log -f --reconnect @api-prod\n  kconsole log --previous default/nginx-0/nginx\n  kconsole log --since 10m --timestamps default/nginx-0/nginx\n  kconsole log --aggregate -n prod -l app=api\n  kconsole log --aggregate -n prod -w deploy/api -c app\n  kconsole log --view -f default/nginx-0/nginx\n  kconsole log --events -f default/nginx-0/nginx",
+		Args:    cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return cl.runConsole(cmd, args)
 		},
 	}
 	cl.command.DisableFlagsInUseLine = true
+	addTargetFlags(cl.command)
+	addLogFlags(cl.command)
+	cl.command.Flags().BoolP(flagFollow, "f", false, "stream the log as it is written")
+	cl.command.Flags().Bool(flagReconnect, false, "with --follow, reconnect when the container restarts")
+	cl.command.Flags().Bool(flagAggregate, false, "follow the logs of all containers of all matching pods together, prefixed by pod/container")
+	cl.command.Flags().Bool(flagRaw, false, "print the log byte for byte, JSON lines are not rendered")
+	cl.command.Flags().StringArray(flagFilter, nil, "only the JSON lines matching field op value, op is = != > >= < <= or ~ for a regexp, e.g. level>=warn, repeatable")
+	cl.command.Flags().StringSlice(flagFields, nil, "the fields of JSON lines shown after time, level and message, e.g. trace_id,user.id")
+	cl.command.Flags().String(flagJq, "", "print only these values of JSON lines, e.g. .request.path,.status")
+	cl.command.Flags().Bool(flagView, false, "page through the log in a full-screen viewer with search, the log keeps streaming")
+	cl.command.Flags().Bool(flagEvents, false, "interleave the events of the pod and the terminations of the container with the log by time")
+}
+
+// renderOptions return the rendering of JSON lines given by the flags, nil with --raw
+func (cl LogCmd) renderOptions(cmd *cobra.Command) (*logRenderOptions, error) {
+	flags := cmd.Flags()
+	raw, err := flags.GetBool(flagRaw)
+	errorx.CheckError(err)
+	filters, err := flags.GetStringArray(flagFilter)
+	errorx.CheckError(err)
+	fields, err := flags.GetStringSlice(flagFields)
+	errorx.CheckError(err)
+	jq, err := flags.GetString(flagJq)
+	errorx.CheckError(err)
+	if raw {
+		if len(filters) > 0 || len(fields) > 0 || jq != "" {
+			return nil, fmt.Errorf("--%s can not be used with --%s, --%s or --%s", flagRaw, flagFilter, flagFields, flagJq)
+		}
+		return nil, nil
+	}
+	return newLogRenderOptions(term.AllowsColorOutput(os.Stdout), filters, fields, jq)
 }
 
 func (cl LogCmd) runConsole(cmd *cobra.Command, args []string) error {
+	render, err := cl.renderOptions(cmd)
+	if err != nil {
+		return err
+	}
+	aggregate, err := cmd.Flags().GetBool(flagAggregate)
+	errorx.CheckError(err)
+	view, err := cmd.Flags().GetBool(flagView)
+	errorx.CheckError(err)
+	events, err := cmd.Flags().GetBool(flagEvents)
+	errorx.CheckError(err)
+	if view && aggregate {
+		return fmt.Errorf("--%s can not be used with --%s", flagView, flagAggregate)
+	}
+	if events && (view || aggregate) {
+		return fmt.Errorf("--%s can not be used with --%s or --%s", flagEvents, flagView, flagAggregate)
+	}
+	if aggregate {
+		return cl.runAggregate(cmd, args, render)
+	}
 	// call utils get pods
-	podname, namespace, selectcontainer := SelectContainer()
+	podname, namespace, selectcontainer := selectTarget(cmd, args)
 	// build exec real command
 	lines, err := cmd.Flags().GetInt64(flagLines)
 	errorx.CheckError(err)
-	err = PrintLogs(namespace, podname, selectcontainer, lines)
+	opts, err := logOptionsFromFlags(cmd, selectcontainer, lines)
+	if err != nil {
+		return err
+	}
+	opts.Follow, err = cmd.Flags().GetBool(flagFollow)
+	errorx.CheckError(err)
+	reconnect, err := cmd.Flags().GetBool(flagReconnect)
+	errorx.CheckError(err)
+	if reconnect && !opts.Follow {
+		return fmt.Errorf("--%s can only be used with --%s", flagReconnect, flagFollow)
+	}
+	if view {
+		return ViewLogs(namespace, podname, opts, render, reconnect)
+	}
+	if events {
+		return PrintLogsWithEvents(namespace, podname, opts, render, reconnect)
+	}
+	err = PrintLogs(namespace, podname, opts, render, reconnect)
 	return err
 }
+
+// runAggregate follow the logs of all pods of the target together until interrupted
+func (cl LogCmd) runAggregate(cmd *cobra.Command, args []string, render *logRenderOptions) error {
+	target, err := targetFromFlags(cmd, args)
+	errorx.CheckErrorWithCode(err, errorx.ErrorArgsErr)
+	lines, err := cmd.Flags().GetInt64(flagLines)
+	errorx.CheckError(err)
+	opts, err := logOptionsFromFlags(cmd, "", lines)
+	if err != nil {
+		return err
+	}
+	if opts.Previous {
+		return fmt.Errorf("--%s can not be used with --%s", flagPrevious, flagAggregate)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return TailPodLogs(ctx, target, opts, render)
+}
@@ -0,0 +1,439 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+)
+
+// the keys holding the time, level and message of a JSON log line, in order of preference
+var (
+	logTimeKeys    = []string{"time", "ts", "timestamp", "@timestamp", "t"}
+	logLevelKeys   = []string{"level", "lvl", "severity", "@level"}
+	logMessageKeys = []string{"msg", "message", "@message"}
+)
+
+// logLevelRanks order the level names, numeric levels of pino and bunyan (10 trace ... 60 fatal) are ranked alike
+var logLevelRanks = map[string]int{
+	"trace": 0, "debug": 1, "info": 2, "notice": 2, "warn": 3, "warning": 3,
+	"error": 4, "err": 4, "fatal": 5, "critical": 5, "crit": 5, "panic": 5, "dpanic": 5,
+}
+
+// logFilterPattern parse a filter, e.g. level>=warn, status!=200 or msg~timeout
+var logFilterPattern = regexp.MustCompile(`^([\w.@-]+)\s*(>=|<=|!=|==|=|>|<|~)\s*(.*)$`)
+
+// logFilter keep the JSON lines whose field compares to the value
+type logFilter struct {
+	field string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+// parseLogFilter parse a filter of the form field op value, op is one of = == != > >= < <= and ~ for a regexp
+func parseLogFilter(s string) (logFilter, error) {
+	m := logFilterPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return logFilter{}, fmt.Errorf("invalid filter %q, expected field op value, e.g. level>=warn", s)
+	}
+	f := logFilter{field: m[1], op: m[2], value: m[3]}
+	if f.op == "~" {
+		re, err := regexp.Compile(f.value)
+		if err != nil {
+			return logFilter{}, fmt.Errorf("invalid filter %q: %v", s, err)
+		}
+		f.re = re
+	}
+	return f, nil
+}
+
+// logLevelRank return the rank of a level name or number
+func logLevelRank(v interface{}) (int, bool) {
+	switch level := v.(type) {
+	case json.Number:
+		n, err := level.Int64()
+		if err != nil || n < 10 {
+			return 0, false
+		}
+		return int(n/10 - 1), true
+	case string:
+		if n, err := strconv.Atoi(level); err == nil {
+			return logLevelRank(json.Number(strconv.Itoa(n)))
+		}
+		rank, ok := logLevelRanks[strings.ToLower(level)]
+		return rank, ok
+	}
+	return 0, false
+}
+
+// compare the field of the entry to the value: levels by rank, numbers numerically, others as strings
+func (f logFilter) match(entry map[string]interface{}) bool {
+	var v interface{}
+	var ok bool
+	if f.field == "level" {
+		_, v, ok = lookupFirst(entry, logLevelKeys)
+	} else {
+		v, ok = lookupPath(entry, strings.Split(f.field, "."))
+	}
+	if !ok {
+		return f.op == "!="
+	}
+	if f.re != nil {
+		return f.re.MatchString(formatLogValue(v))
+	}
+	cmp, comparable := 0, false
+	if f.field == "level" {
+		a, okA := logLevelRank(v)
+		b, okB := logLevelRank(f.value)
+		if okA && okB {
+			cmp, comparable = a-b, true
+		}
+	}
+	if !comparable {
+		if n, isNumber := v.(json.Number); isNumber {
+			a, errA := n.Float64()
+			b, errB := strconv.ParseFloat(f.value, 64)
+			if errA == nil && errB == nil {
+				cmp, comparable = compareFloat(a, b), true
+			}
+		}
+	}
+	if !comparable {
+		cmp = strings.Compare(formatLogValue(v), f.value)
+	}
+	switch f.op {
+	case "=", "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// lookupFirst return the first of the keys present in the entry
+func lookupFirst(entry map[string]interface{}, keys []string) (string, interface{}, bool) {
+	for _, key := range keys {
+		if v, ok := entry[key]; ok {
+			return key, v, true
+		}
+	}
+	return "", nil, false
+}
+
+// lookupPath return the value at the path of keys and array indexes in the entry
+func lookupPath(entry interface{}, path []string) (interface{}, bool) {
+	v := entry
+	for _, step := range path {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			next, ok := node[step]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []interface{}:
+			i, err := strconv.Atoi(step)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// parseJqPath parse a jq-like path, e.g. .request.headers[0].name, into its steps
+func parseJqPath(s string) ([]string, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, ".") {
+		return nil, fmt.Errorf("invalid path %q, expected .field.subfield[index]", s)
+	}
+	var path []string
+	for _, part := range strings.Split(s[1:], ".") {
+		if part == "" {
+			continue
+		}
+		for {
+			i := strings.IndexByte(part, '[')
+			if i < 0 {
+				path = append(path, part)
+				break
+			}
+			j := strings.IndexByte(part, ']')
+			if j < i {
+				return nil, fmt.Errorf("invalid path %q, unbalanced [", s)
+			}
+			if i > 0 {
+				path = append(path, part[:i])
+			}
+			path = append(path, part[i+1:j])
+			part = part[j+1:]
+			if part == "" {
+				break
+			}
+		}
+	}
+	return path, nil
+}
+
+// formatLogValue render a JSON value, strings as they are and others as compact JSON
+func formatLogValue(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case nil:
+		return "null"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// formatLogTime render the time of an entry, epoch seconds or milliseconds are converted to RFC3339
+func formatLogTime(v interface{}) string {
+	n, ok := v.(json.Number)
+	if !ok {
+		return formatLogValue(v)
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return n.String()
+	}
+	if f > 1e12 {
+		f /= 1000
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*1e9)).UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// logRenderOptions the rendering of JSON log lines
+type logRenderOptions struct {
+	color   bool
+	filters []logFilter
+	// fields the fields shown after the time, level and message, all other fields if empty
+	fields []string
+	// paths the values printed instead of the line, like jq
+	paths [][]string
+}
+
+// newLogRenderOptions parse the filters, fields and jq-like paths, the paths are separated by commas
+func newLogRenderOptions(color bool, filters []string, fields []string, jq string) (*logRenderOptions, error) {
+	o := &logRenderOptions{color: color, fields: fields}
+	for _, s := range filters {
+		f, err := parseLogFilter(s)
+		if err != nil {
+			return nil, err
+		}
+		o.filters = append(o.filters, f)
+	}
+	if jq != "" {
+		for _, s := range strings.Split(jq, ",") {
+			path, err := parseJqPath(s)
+			if err != nil {
+				return nil, err
+			}
+			o.paths = append(o.paths, path)
+		}
+	}
+	return o, nil
+}
+
+// newWriter return a writer rendering the lines written to it onto out
+func (o *logRenderOptions) newWriter(out io.Writer) *logRenderer {
+	r := &logRenderer{options: o, out: out, lastShown: true}
+	r.lineWriter = newLineWriter(r.writeLine)
+	return r
+}
+
+// logRenderer render JSON log lines as `time level message key=value...` coloured by level. Lines that are
+// not JSON are written as they are, and when filtering they follow the JSON line before them, e.g. a stack trace.
+type logRenderer struct {
+	*lineWriter
+	options   *logRenderOptions
+	out       io.Writer
+	lastShown bool
+}
+
+func (r *logRenderer) writeLine(line []byte) error {
+	rendered, shown, isEntry := r.options.render(string(bytes.TrimSuffix(line, []byte("\n"))), r.lastShown)
+	if isEntry {
+		r.lastShown = shown
+	}
+	if !shown {
+		return nil
+	}
+	_, err := io.WriteString(r.out, rendered+"\n")
+	return err
+}
+
+// render a line, reporting whether it is shown and whether it is a JSON line. lastShown tell whether the JSON
+// line before was shown.
+func (o *logRenderOptions) render(line string, lastShown bool) (rendered string, shown bool, isEntry bool) {
+	// a timestamp added by --timestamps is kept in front
+	prefix, body := "", line
+	if _, stamp, rest, ok := splitTimestamp(line); ok {
+		prefix, body = stamp+" ", rest
+	}
+	entry, ok := parseLogEntry(body)
+	if !ok {
+		if len(o.paths) > 0 || (len(o.filters) > 0 && !lastShown) {
+			return "", false, false
+		}
+		return line, true, false
+	}
+	for _, f := range o.filters {
+		if !f.match(entry) {
+			return "", false, true
+		}
+	}
+	if len(o.paths) > 0 {
+		values := make([]string, 0, len(o.paths))
+		for _, path := range o.paths {
+			if v, ok := lookupPath(entry, path); ok {
+				values = append(values, formatLogValue(v))
+			}
+		}
+		if len(values) == 0 {
+			return "", false, true
+		}
+		return prefix + strings.Join(values, " "), true, true
+	}
+	return prefix + o.format(entry), true, true
+}
+
+// parseLogEntry parse a JSON object line, keeping numbers as they are written
+func parseLogEntry(line string) (map[string]interface{}, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil, false
+	}
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	var entry map[string]interface{}
+	if err := dec.Decode(&entry); err != nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+// format render the entry as `time level message key=value...`
+func (o *logRenderOptions) format(entry map[string]interface{}) string {
+	var parts []string
+	used := map[string]bool{}
+	if key, v, ok := lookupFirst(entry, logTimeKeys); ok {
+		used[key] = true
+		parts = append(parts, o.paint(pterm.FgGray, formatLogTime(v)))
+	}
+	if key, v, ok := lookupFirst(entry, logLevelKeys); ok {
+		used[key] = true
+		parts = append(parts, o.paintLevel(v))
+	}
+	if key, v, ok := lookupFirst(entry, logMessageKeys); ok {
+		used[key] = true
+		parts = append(parts, formatLogValue(v))
+	}
+	fields := o.fields
+	if len(fields) == 0 {
+		for key := range entry {
+			if !used[key] {
+				fields = append(fields, key)
+			}
+		}
+		sort.Strings(fields)
+	}
+	for _, field := range fields {
+		v, ok := lookupPath(entry, strings.Split(field, "."))
+		if !ok || used[field] {
+			continue
+		}
+		value := formatLogValue(v)
+		if _, isString := v.(string); isString && (value == "" || strings.ContainsAny(value, " \t\"=")) {
+			value = strconv.Quote(value)
+		}
+		parts = append(parts, o.paint(pterm.FgCyan, field+"=")+value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// paintLevel render the level in upper case, coloured by its rank
+func (o *logRenderOptions) paintLevel(v interface{}) string {
+	text := strings.ToUpper(formatLogValue(v))
+	rank, ok := logLevelRank(v)
+	if !ok {
+		return text
+	}
+	names := []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+	if _, isNumber := v.(json.Number); isNumber && rank < len(names) {
+		text = names[rank]
+	}
+	text = fmt.Sprintf("%-5s", text)
+	switch {
+	case rank >= 4:
+		return o.paint(pterm.FgRed, text)
+	case rank == 3:
+		return o.paint(pterm.FgYellow, text)
+	case rank == 2:
+		return o.paint(pterm.FgGreen, text)
+	}
+	return o.paint(pterm.FgGray, text)
+}
+
+func (o *logRenderOptions) paint(color pterm.Color, text string) string {
+	if !o.color {
+		return text
+	}
+	return color.Sprint(text)
+}
@@ -0,0 +1,129 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogFilterMatch(t *testing.T) {
+	cases := []struct {
+		filter string
+		line   string
+		want   bool
+	}{
+		{"level>=warn", `{"level":"error"}`, true},
+		{"level>=warn", `{"level":"info"}`, false},
+		{"level>=warn", `{"level":40}`, true},
+		{"level>=warn", `{"severity":"WARNING"}`, true},
+		{"status>=500", `{"status":503}`, true},
+		{"status>=500", `{"status":404}`, false},
+		{"req.path=/healthz", `{"req":{"path":"/healthz"}}`, true},
+		{"msg~time(out|d out)", `{"msg":"dial timeout"}`, true},
+		{"user!=admin", `{"msg":"no user"}`, true},
+		{"user=admin", `{"msg":"no user"}`, false},
+	}
+	for _, c := range cases {
+		f, err := parseLogFilter(c.filter)
+		assert.NoError(t, err, c.filter)
+		entry, ok := parseLogEntry(c.line)
+		assert.True(t, ok, c.line)
+		assert.Equal(t, c.want, f.match(entry), "%s on %s", c.filter, c.line)
+	}
+
+	_, err := parseLogFilter("level")
+	assert.Error(t, err)
+	_, err = parseLogFilter("msg~(")
+	assert.Error(t, err)
+}
+
+func TestParseJqPath(t *testing.T) {
+	path, err := parseJqPath(".request.headers[0].name")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"request", "headers", "0", "name"}, path)
+
+	path, err = parseJqPath(".items[1][2]")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"items", "1", "2"}, path)
+
+	_, err = parseJqPath("request")
+	assert.Error(t, err)
+	_, err = parseJqPath(".a]b[")
+	assert.Error(t, err)
+}
+
+func TestLogRender(t *testing.T) {
+	o, err := newLogRenderOptions(false, nil, nil, "")
+	assert.NoError(t, err)
+	line, shown, isEntry := o.render(`{"time":"2023-05-01T10:00:00Z","level":"info","msg":"started","port":8080,"name":"api server"}`, true)
+	assert.True(t, shown)
+	assert.True(t, isEntry)
+	assert.Equal(t, `2023-05-01T10:00:00Z INFO  started name="api server" port=8080`, line)
+
+	line, _, _ = o.render(`2023-05-01T10:00:01.5Z {"ts":1682935200,"level":50,"msg":"failed"}`, true)
+	assert.Equal(t, `2023-05-01T10:00:01.5Z 2023-05-01T10:00:00.000Z ERROR failed`, line)
+
+	line, shown, isEntry = o.render("plain text", true)
+	assert.Equal(t, "plain text", line)
+	assert.True(t, shown)
+	assert.False(t, isEntry)
+
+	o, err = newLogRenderOptions(false, nil, []string{"msg", "trace_id"}, "")
+	assert.NoError(t, err)
+	line, _, _ = o.render(`{"level":"warn","msg":"slow","trace_id":"abc","user":"bob"}`, true)
+	assert.Equal(t, "WARN  slow trace_id=abc", line)
+
+	o, err = newLogRenderOptions(false, nil, nil, ".req.path, .status")
+	assert.NoError(t, err)
+	line, shown, _ = o.render(`{"req":{"path":"/api"},"status":200}`, true)
+	assert.True(t, shown)
+	assert.Equal(t, "/api 200", line)
+	_, shown, _ = o.render(`{"msg":"other"}`, true)
+	assert.False(t, shown)
+	_, shown, _ = o.render("plain text", true)
+	assert.False(t, shown)
+
+	_, err = newLogRenderOptions(false, []string{"level"}, nil, "")
+	assert.Error(t, err)
+}
+
+func TestLogRendererFilterContinuation(t *testing.T) {
+	o, err := newLogRenderOptions(false, []string{"level>=error"}, nil, "")
+	assert.NoError(t, err)
+	var out bytes.Buffer
+	w := o.newWriter(&out)
+	input := `{"level":"info","msg":"ok"}
+	at hidden.go:1
+{"level":"error","msg":"boom"}
+	at main.go:10
+{"level":"info","msg":"ok"}
+trailing`
+	for _, chunk := range []string{input[:20], input[20:50], input[50:]} {
+		_, err := w.Write([]byte(chunk))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "ERROR boom\n\tat main.go:10\n", out.String())
+}
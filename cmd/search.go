@@ -0,0 +1,184 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/manifoldco/promptui"
+)
+
+// scores of fuzzyScore
+const (
+	scoreMatch          = 1
+	scoreConsecutive    = 5
+	scoreFirstBoundary  = 6
+	scoreBoundary       = 3
+	penaltyGapStart     = 2
+	maxPenaltyGapLength = 3
+)
+
+// isWordBoundary report whether a word starts after r
+func isWordBoundary(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// matchFrom score the subsequence match of token in text starting at start
+func matchFrom(token, text []rune, start int) (int, bool) {
+	score, prev, ti := 0, -1, 0
+	for i := start; i < len(text) && ti < len(token); i++ {
+		if text[i] != token[ti] {
+			continue
+		}
+		score += scoreMatch
+		if i == 0 || isWordBoundary(text[i-1]) {
+			if ti == 0 {
+				score += scoreFirstBoundary
+			} else {
+				score += scoreBoundary
+			}
+		}
+		if prev >= 0 {
+			if gap := i - prev - 1; gap == 0 {
+				score += scoreConsecutive
+			} else if gap < maxPenaltyGapLength {
+				score -= penaltyGapStart + gap
+			} else {
+				score -= penaltyGapStart + maxPenaltyGapLength
+			}
+		}
+		prev = i
+		ti++
+	}
+	return score, ti == len(token)
+}
+
+// tokenScore return the best score of the token as a subsequence of text
+func tokenScore(token, text []rune) (best int, found bool) {
+	for start := range text {
+		if text[start] != token[0] {
+			continue
+		}
+		if score, ok := matchFrom(token, text, start); ok && (!found || score > best) {
+			best, found = score, true
+		}
+	}
+	return
+}
+
+// fuzzyScore score how well text matches the query. Every whitespace separated token of the query must
+// be a case-insensitive subsequence of text, in any order; consecutive characters and characters starting
+// a word score higher.
+func fuzzyScore(query, text string) (int, bool) {
+	lowerText := []rune(strings.ToLower(text))
+	total := 0
+	for _, token := range strings.Fields(strings.ToLower(query)) {
+		score, ok := tokenScore([]rune(token), lowerText)
+		if !ok {
+			return 0, false
+		}
+		total += score
+	}
+	return total, true
+}
+
+// rankedSlot a position of a rankedList, holding the item currently shown there
+type rankedSlot struct {
+	Index int
+	Item  interface{}
+}
+
+func (s *rankedSlot) String() string {
+	return fmt.Sprint(s.Item)
+}
+
+// rankedList order the items of a promptui.Select by the fuzzy score of the search input.
+// promptui can only filter items, so the select lists slots whose items are reordered on every search.
+type rankedList struct {
+	items   []interface{}
+	keys    []string
+	slots   []*rankedSlot
+	matched int
+}
+
+func newRankedList(items []interface{}, keys []string) *rankedList {
+	r := &rankedList{items: items, keys: keys, matched: len(items)}
+	for i, item := range items {
+		r.slots = append(r.slots, &rankedSlot{Index: i, Item: item})
+	}
+	return r
+}
+
+// rank fill the slots with the items matching the input by descending score, followed by the others
+func (r *rankedList) rank(input string) {
+	type scored struct {
+		index int
+		score int
+	}
+	matched := make([]scored, 0, len(r.items))
+	unmatched := make([]int, 0)
+	for i, key := range r.keys {
+		if score, ok := fuzzyScore(input, key); ok {
+			matched = append(matched, scored{index: i, score: score})
+		} else {
+			unmatched = append(unmatched, i)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].score > matched[j].score
+	})
+	order := make([]int, 0, len(r.items))
+	for _, m := range matched {
+		order = append(order, m.index)
+	}
+	order = append(order, unmatched...)
+	for pos, index := range order {
+		r.slots[pos].Index = index
+		r.slots[pos].Item = r.items[index]
+	}
+	r.matched = len(matched)
+}
+
+// search is the promptui searcher of the list, it is called for every slot in order on each search
+func (r *rankedList) search(input string, index int) bool {
+	if index == 0 {
+		r.rank(input)
+	}
+	return index < r.matched
+}
+
+// runRankedSelect run the select over items, ordered by the fuzzy score of their keys while searching.
+// The templates of prompt render a *rankedSlot, the item is its Item field. The index of the selected
+// item in items is returned.
+func runRankedSelect(prompt promptui.Select, items []interface{}, keys []string) (int, error) {
+	ranked := newRankedList(items, keys)
+	prompt.Items = ranked.slots
+	prompt.Searcher = ranked.search
+	index, _, err := prompt.Run()
+	if err != nil {
+		return 0, err
+	}
+	return ranked.slots[index].Index, nil
+}
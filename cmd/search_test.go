@@ -0,0 +1,70 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	// case-insensitive
+	_, ok := fuzzyScore("API", "prod#api-7d9f8b6c5-x2x9z")
+	assert.True(t, ok)
+	// tokens match in any order
+	_, ok = fuzzyScore("api prod", "prod#api-7d9f8b6c5-x2x9z")
+	assert.True(t, ok)
+	// every token must match
+	_, ok = fuzzyScore("api web", "prod#api-7d9f8b6c5-x2x9z")
+	assert.False(t, ok)
+	// subsequence match
+	_, ok = fuzzyScore("apx2", "prod#api-7d9f8b6c5-x2x9z")
+	assert.True(t, ok)
+
+	boundary, _ := fuzzyScore("api", "prod#api-0")
+	inWord, _ := fuzzyScore("api", "prod#rapid-0")
+	scattered, _ := fuzzyScore("api", "prod#a-p-i-0")
+	assert.Greater(t, boundary, inWord)
+	assert.Greater(t, inWord, scattered)
+}
+
+func TestRankedList(t *testing.T) {
+	keys := []string{"rapid-0", "web-0", "api-0"}
+	items := []interface{}{"rapid-0", "web-0", "api-0"}
+	ranked := newRankedList(items, keys)
+
+	var shown []string
+	for i := range keys {
+		if ranked.search("api", i) {
+			shown = append(shown, ranked.slots[i].String())
+		}
+	}
+	assert.Equal(t, []string{"api-0", "rapid-0"}, shown)
+	assert.Equal(t, 2, ranked.slots[0].Index)
+
+	// an empty input restores the original order
+	for i := range keys {
+		assert.True(t, ranked.search("", i))
+	}
+	assert.Equal(t, "rapid-0", ranked.slots[0].String())
+}
@@ -0,0 +1,114 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"kconsole/utils/errorx"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// parseServiceRef parse a service given as ns/name or name, the namespace defaults to namespace
+func parseServiceRef(ref string, namespace string) (ns, name string, err error) {
+	parts := strings.Split(ref, "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		ns, name = namespace, parts[0]
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		ns, name = parts[0], parts[1]
+	default:
+		return "", "", fmt.Errorf("invalid service %q, expected ns/name or name", ref)
+	}
+	if ns == "" {
+		return "", "", fmt.Errorf("the namespace of service %q is required, use ns/name or --namespace", ref)
+	}
+	return ns, name, nil
+}
+
+// endpointReadiness return the readiness of the pods backing the service by pod name, read from its EndpointSlices
+func endpointReadiness(namespace, service string) (map[string]bool, error) {
+	slices, err := getClientSet().DiscoveryV1().EndpointSlices(namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: discoveryv1.LabelServiceName + "=" + service,
+	})
+	if err != nil {
+		return nil, err
+	}
+	readiness := map[string]bool{}
+	for _, slice := range slices.Items {
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.TargetRef == nil || endpoint.TargetRef.Kind != "Pod" {
+				continue
+			}
+			// an unknown readiness is interpreted as ready
+			ready := endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready
+			readiness[endpoint.TargetRef.Name] = readiness[endpoint.TargetRef.Name] || ready
+		}
+	}
+	return readiness, nil
+}
+
+// sortByReadiness move the pods which are ready endpoints to the front, keeping the order otherwise
+func sortByReadiness(pods []v1.Pod, readiness map[string]bool) {
+	sort.SliceStable(pods, func(i, j int) bool {
+		return readiness[pods[i].Name] && !readiness[pods[j].Name]
+	})
+}
+
+// ServicePods list the pods backing the service, ready endpoints first. The pods are selected by the
+// selector of the service, or by its EndpointSlices when the service has no selector.
+func ServicePods(namespace, name string) []v1.Pod {
+	ctx := context.Background()
+	svc, err := getClientSet().CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	errorx.CheckError(err)
+	readiness, err := endpointReadiness(namespace, name)
+	if err != nil && !k8serror.IsForbidden(err) {
+		errorx.CheckError(err)
+	}
+
+	var pods []v1.Pod
+	if len(svc.Spec.Selector) > 0 {
+		selector := labels.SelectorFromSet(svc.Spec.Selector).String()
+		pods = podList(namespace, metav1.ListOptions{LabelSelector: selector}).Items
+	} else {
+		for podname := range readiness {
+			pod, err := getClientSet().CoreV1().Pods(namespace).Get(ctx, podname, metav1.GetOptions{})
+			if k8serror.IsNotFound(err) {
+				continue
+			}
+			errorx.CheckError(err)
+			pods = append(pods, *pod)
+		}
+		sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	}
+	if readiness != nil {
+		sortByReadiness(pods, readiness)
+	}
+	return pods
+}
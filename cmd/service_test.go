@@ -0,0 +1,59 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseServiceRef(t *testing.T) {
+	ns, name, err := parseServiceRef("prod/api", "default")
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", ns)
+	assert.Equal(t, "api", name)
+
+	ns, _, err = parseServiceRef("api", "default")
+	assert.NoError(t, err)
+	assert.Equal(t, "default", ns)
+
+	for _, invalid := range []string{"", "prod/", "a/b/c"} {
+		_, _, err = parseServiceRef(invalid, "default")
+		assert.Error(t, err, invalid)
+	}
+	// the namespace is required
+	_, _, err = parseServiceRef("api", "")
+	assert.Error(t, err)
+}
+
+func TestSortByReadiness(t *testing.T) {
+	pods := []v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "api-0"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "api-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "api-2"}},
+	}
+	sortByReadiness(pods, map[string]bool{"api-0": false, "api-2": true})
+	assert.Equal(t, []string{"api-2", "api-0", "api-1"}, []string{pods[0].Name, pods[1].Name, pods[2].Name})
+}
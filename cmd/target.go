@@ -0,0 +1,242 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"fmt"
+	"kconsole/config"
+	"kconsole/utils/errorx"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagNamespace     = "namespace"
+	flagPod           = "pod"
+	flagContainer     = "container"
+	flagSelector      = "selector"
+	flagFieldSelector = "field-selector"
+	flagAllNamespaces = "all-namespaces"
+	flagPickNamespace = "pick-namespace"
+	flagWorkload      = "workload"
+	flagPickWorkload  = "pick-workload"
+	flagAutoPick      = "auto-pick"
+	flagService       = "service"
+	flagAllContainers = "all-containers"
+)
+
+// bookmarkPrefix marks a positional target naming a bookmark, e.g. @api-prod
+const bookmarkPrefix = "@"
+
+// Target identifies a container incluster. Empty fields are selected interactively.
+type Target struct {
+	Namespace string
+	Pod       string
+	Container string
+	// Selector and FieldSelector filter the pods to select from
+	Selector      string
+	FieldSelector string
+	// AllNamespaces ignore the default namespace of the cluster
+	AllNamespaces bool
+	// PickNamespace select a namespace before selecting a pod
+	PickNamespace bool
+	// Workload select a pod of the workload, given as kind/name
+	Workload string
+	// PickWorkload select a workload before selecting a pod
+	PickWorkload bool
+	// AutoPick pick a ready pod instead of prompting
+	AutoPick bool
+	// Service select a pod backing the service, given as ns/name or name
+	Service string
+	// AllContainers list every container, the default container and sidecars are not handled specially
+	AllContainers bool
+}
+
+// byWorkload report whether the pods are selected through a workload
+func (t Target) byWorkload() bool {
+	return t.Workload != "" || t.PickWorkload
+}
+
+// addListFlags register the flags used to filter the pods to select from.
+func addListFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringP(flagNamespace, "n", "", "namespace of the pod, the default namespace of the cluster if not set")
+	flags.StringP(flagSelector, "l", "", "label selector of the pods, e.g. app=nginx")
+	flags.String(flagFieldSelector, "", "field selector of the pods, e.g. status.phase=Running")
+	flags.BoolP(flagAllNamespaces, "A", false, "list pods in all namespaces, ignoring the default namespace")
+	flags.Bool(flagPickNamespace, false, "select a namespace before selecting a pod")
+	flags.StringP(flagWorkload, "w", "", "select the pods of a workload, e.g. deploy/api, sts/db, ds/agent or job/migrate")
+	flags.Bool(flagPickWorkload, false, "select a deployment, statefulset, daemonset or job before selecting a pod")
+	flags.Bool(flagAutoPick, false, "pick a ready pod automatically instead of prompting")
+	flags.String(flagService, "", "select the pods backing a service, given as ns/name or name, ready endpoints first")
+}
+
+// addTargetFlags register the flags used to select a container without prompts.
+func addTargetFlags(cmd *cobra.Command) {
+	addListFlags(cmd)
+	flags := cmd.Flags()
+	flags.String(flagPod, "", "name of the pod")
+	flags.StringP(flagContainer, "c", "", "name of the container")
+	flags.Bool(flagAllContainers, false, "choose from all containers, even when the pod has a default container, sidecars are not hidden")
+}
+
+// parseTarget parse a positional target of the form ns/pod[/container].
+func parseTarget(s string) (Target, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Target{}, fmt.Errorf("invalid target %q, expected ns/pod[/container]", s)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return Target{}, fmt.Errorf("invalid target %q, expected ns/pod[/container]", s)
+		}
+	}
+	t := Target{Namespace: parts[0], Pod: parts[1]}
+	if len(parts) == 3 {
+		t.Container = parts[2]
+	}
+	return t, nil
+}
+
+// applyBookmark fill the fields not given by flags from the bookmark
+func (t *Target) applyBookmark(b config.Bookmark) {
+	if t.Namespace == "" && !t.AllNamespaces {
+		t.Namespace = b.Namespace
+	}
+	if t.Selector == "" {
+		t.Selector = b.Selector
+	}
+	if t.Container == "" {
+		t.Container = b.Container
+	}
+}
+
+// isBookmarkRef report whether the positional target names a bookmark
+func isBookmarkRef(s string) bool {
+	return strings.HasPrefix(s, bookmarkPrefix)
+}
+
+// lookupBookmark return the bookmark named by the positional target @name
+func lookupBookmark(ref string) (config.Bookmark, error) {
+	name := strings.TrimPrefix(ref, bookmarkPrefix)
+	b, ok := config.GetKconsoleConfig().GetBookmark(name)
+	if !ok {
+		return b, fmt.Errorf("bookmark %q not found, run 'kconsole bookmark list' to see the bookmarks", name)
+	}
+	return b, nil
+}
+
+// bookmarkCommand return the command of the bookmark named by the positional target, if any
+func bookmarkCommand(args []string) []string {
+	if len(args) == 0 || !isBookmarkRef(args[0]) {
+		return nil
+	}
+	b, err := lookupBookmark(args[0])
+	if err != nil {
+		return nil
+	}
+	return b.Command
+}
+
+// mergeTargetField merge a value given by flag into the one given by the positional target.
+func mergeTargetField(name, positional, flag string) (string, error) {
+	if flag == "" {
+		return positional, nil
+	}
+	if positional != "" && positional != flag {
+		return "", fmt.Errorf("%s is set to both %q and %q", name, positional, flag)
+	}
+	return flag, nil
+}
+
+// targetFromFlags build the target from the optional positional argument and the targeting flags.
+// The positional argument may name a bookmark as @name, its cluster is used and the flags override its fields.
+func targetFromFlags(cmd *cobra.Command, args []string) (t Target, err error) {
+	var bookmark *config.Bookmark
+	if len(args) > 0 && isBookmarkRef(args[0]) {
+		b, err := lookupBookmark(args[0])
+		if err != nil {
+			return t, err
+		}
+		bookmark = &b
+	} else if len(args) > 0 {
+		if t, err = parseTarget(args[0]); err != nil {
+			return
+		}
+	}
+	flags := cmd.Flags()
+	for name, field := range map[string]*bool{
+		flagAllNamespaces: &t.AllNamespaces,
+		flagPickNamespace: &t.PickNamespace,
+		flagPickWorkload:  &t.PickWorkload,
+		flagAutoPick:      &t.AutoPick,
+		flagAllContainers: &t.AllContainers,
+	} {
+		if flags.Lookup(name) == nil {
+			continue
+		}
+		if *field, err = flags.GetBool(name); err != nil {
+			return t, err
+		}
+	}
+	for name, field := range map[string]*string{
+		flagNamespace:     &t.Namespace,
+		flagPod:           &t.Pod,
+		flagContainer:     &t.Container,
+		flagSelector:      &t.Selector,
+		flagFieldSelector: &t.FieldSelector,
+		flagWorkload:      &t.Workload,
+		flagService:       &t.Service,
+	} {
+		if flags.Lookup(name) == nil {
+			continue
+		}
+		val, err := flags.GetString(name)
+		if err != nil {
+			return t, err
+		}
+		if *field, err = mergeTargetField(name, *field, val); err != nil {
+			return t, err
+		}
+	}
+	if t.AllNamespaces && t.Namespace != "" {
+		return t, fmt.Errorf("--%s can not be used with a namespace", flagAllNamespaces)
+	}
+	if bookmark != nil {
+		t.applyBookmark(*bookmark)
+		if err = useCluster(bookmark.Auth, bookmark.Cluster); err != nil {
+			return t, err
+		}
+	}
+	return t, nil
+}
+
+// selectTarget resolve the container from args and flags, prompting only for what is missing.
+// The container is recorded in the history of the command.
+func selectTarget(cmd *cobra.Command, args []string) (pod, ns, container string) {
+	t, err := targetFromFlags(cmd, args)
+	errorx.CheckErrorWithCode(err, errorx.ErrorArgsErr)
+	p, container := selectPodContainer(t)
+	recordHistory(cmd.Name(), p, container)
+	return p.Name, p.Namespace, container
+}
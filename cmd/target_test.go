@@ -0,0 +1,77 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"kconsole/config"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTarget(t *testing.T) {
+	target, err := parseTarget("default/nginx-0")
+	assert.NoError(t, err)
+	assert.Equal(t, Target{Namespace: "default", Pod: "nginx-0"}, target)
+
+	target, err = parseTarget("default/nginx-0/nginx")
+	assert.NoError(t, err)
+	assert.Equal(t, Target{Namespace: "default", Pod: "nginx-0", Container: "nginx"}, target)
+
+	for _, invalid := range []string{"nginx-0", "default/", "/nginx-0", "a/b/c/d"} {
+		_, err = parseTarget(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestTargetFromFlags(t *testing.T) {
+	cmd := &cobra.Command{}
+	addTargetFlags(cmd)
+	assert.NoError(t, cmd.ParseFlags([]string{"-c", "nginx"}))
+
+	target, err := targetFromFlags(cmd, []string{"default/nginx-0"})
+	assert.NoError(t, err)
+	assert.Equal(t, Target{Namespace: "default", Pod: "nginx-0", Container: "nginx"}, target)
+
+	// flags must not contradict the positional target
+	_, err = targetFromFlags(cmd, []string{"default/nginx-0/sidecar"})
+	assert.Error(t, err)
+}
+
+func TestTargetApplyBookmark(t *testing.T) {
+	b := config.Bookmark{Name: "api-prod", Namespace: "prod", Selector: "app=api", Container: "app"}
+
+	target := Target{}
+	target.applyBookmark(b)
+	assert.Equal(t, Target{Namespace: "prod", Selector: "app=api", Container: "app"}, target)
+
+	// flags override the bookmark
+	target = Target{AllNamespaces: true, Container: "istio-proxy"}
+	target.applyBookmark(b)
+	assert.Equal(t, Target{AllNamespaces: true, Selector: "app=api", Container: "istio-proxy"}, target)
+}
+
+func TestBookmarkSummary(t *testing.T) {
+	b := config.Bookmark{Name: "api-prod", Cluster: "prod", Namespace: "prod", Selector: "app=api", Container: "app", Command: []string{"bash", "-l"}}
+	assert.Equal(t, "--cluster prod -n prod -l app=api -c app -- bash -l", bookmarkSummary(b))
+}
@@ -31,23 +31,28 @@ type UploadCmd struct {
 
 func (cl *UploadCmd) Init() {
 	cl.command = &cobra.Command{
-		Use:   "upload",
-		Short: "Copy files locally to remote",
-		Long:  "Copy files locally to remote",
+		Use:     "upload",
+		Short:   "Copy files locally to remote",
+		Long:    "Copy files locally to remote",
+		Example: "  kconsole upload\n  kconsole upload default/nginx-0/nginx --src ./nginx.conf --dest /etc/nginx/nginx.conf",
+		Args:    cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return cl.runUpload(cmd, args)
 		},
 	}
 	cl.command.DisableFlagsInUseLine = true
+	addTargetFlags(cl.command)
+	cl.command.Flags().String(flagSrc, "", "local source file path")
+	cl.command.Flags().String(flagDest, "", "container destination file path")
 }
 
 func (cl UploadCmd) runUpload(cmd *cobra.Command, args []string) error {
 	// call utils get pods
-	podname, namespace, selectcontainer := SelectContainer()
+	podname, namespace, selectcontainer := selectTarget(cmd, args)
 	// input src file
-	inputsourcecmd := InputUI("input local source file path", "local", "")
+	inputsourcecmd := flagOrInputUI(cmd, flagSrc, "input local source file path", "local", "")
 	// input dest file
-	inputdestcmd := InputUI("input container dest file path", "/", "")
+	inputdestcmd := flagOrInputUI(cmd, flagDest, "input container dest file path", "/", "")
 	// build exec real command
 	err := copyToPod(namespace, podname, selectcontainer, inputsourcecmd, inputdestcmd)
 	return err
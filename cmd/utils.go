@@ -23,8 +23,8 @@ package cmd
 
 import (
 	"archive/tar"
-	"bytes"
 	"context"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"kconsole/config"
@@ -32,29 +32,41 @@ import (
 	"kconsole/utils/errorx"
 	"net/http"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 
 	"github.com/manifoldco/promptui"
 	"github.com/pingcap/errors"
 	"github.com/pterm/pterm"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
 	k8serror "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/kubectl/pkg/util/term"
 )
 
 var (
-	once      sync.Once
-	clientSet *kubernetes.Clientset = &kubernetes.Clientset{}
+	once          sync.Once
+	clientSetOnce sync.Once
+	restConfig    *rest.Config          = &rest.Config{}
+	clientSet     *kubernetes.Clientset = &kubernetes.Clientset{}
+	// restConfigBuilt whether getRestConfig has built the rest config, the cluster can not be changed afterwards
+	restConfigBuilt bool
+	// kubeContext the context of ~/.kube/config used instead of its current context
+	kubeContext string
 )
 
 // ----
@@ -83,21 +95,71 @@ func PrintLogo() string {
 // kube utils
 // ----
 
-// defaultKubeConfig used to configure the kubeclient by ~/.kube/config
-func defaultKubeConfig() *rest.Config {
+// kubeConfigPath return the path of ~/.kube/config
+func kubeConfigPath() string {
 	home, err := os.UserHomeDir()
 	errorx.CheckError(err)
 
 	// 构建kubeconfig文件路径
-	kubeconfig := filepath.Join(home, ".kube", "config")
+	return filepath.Join(home, ".kube", "config")
+}
 
+// defaultKubeConfig used to configure the kubeclient by ~/.kube/config
+func defaultKubeConfig() *rest.Config {
 	// 加载kubeconfig文件
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	rules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeConfigPath()}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
 	errorx.CheckError(err)
 
 	return config
 }
 
+// currentClusterName return the name identifying the active cluster in config,
+// the current context of ~/.kube/config or the bcs cluster id
+func currentClusterName() string {
+	c := config.GetKconsoleConfig()
+	if c.Auth == config.BcsAuth {
+		return c.BCSCluster
+	}
+	if kubeContext != "" {
+		return kubeContext
+	}
+	kubeconfig, err := clientcmd.LoadFromFile(kubeConfigPath())
+	errorx.CheckError(err)
+	return kubeconfig.CurrentContext
+}
+
+// useCluster make this run talk to the cluster, a kube context or bcs cluster id, with the auth backend
+// instead of the configured ones. Empty values keep the configured ones and the config file is not changed.
+func useCluster(auth string, cluster string) error {
+	if restConfigBuilt {
+		return fmt.Errorf("the cluster must be chosen before connecting to it")
+	}
+	c := config.GetKconsoleConfig()
+	switch auth {
+	case "":
+	case config.LocalConfigAuth:
+		c.Auth = auth
+	case config.BcsAuth:
+		if c.BCSHost == "" || c.BCSToken == "" {
+			return fmt.Errorf("auth %s is not configured, run 'kconsole login --mode bcs' first", auth)
+		}
+		c.Auth = auth
+	default:
+		return fmt.Errorf("auth:%s is not vaild, must be the `bcs` or `local`", auth)
+	}
+	if cluster == "" {
+		return nil
+	}
+	if c.Auth == config.BcsAuth {
+		c.BCSCluster = cluster
+	} else {
+		kubeContext = cluster
+	}
+	return nil
+}
+
 func newKubeConfigForToken(host string, token string) *rest.Config {
 	config := &rest.Config{
 		Host:        host,
@@ -114,33 +176,33 @@ func newBcsConfig(clusterid string) *rest.Config {
 	)
 }
 
-func bcsClientSet(clusterid string) *kubernetes.Clientset {
-	config := newBcsConfig(clusterid)
-	clientset, err := kubernetes.NewForConfig(config)
-	errorx.CheckError(err)
-	return clientset
-}
-
-func defaulClientSet() *kubernetes.Clientset {
-	config := defaultKubeConfig()
-	clientset, err := kubernetes.NewForConfig(config)
-	errorx.CheckError(err)
-	return clientset
-}
-
-func getClientSet() *kubernetes.Clientset {
+// getRestConfig return the rest config of the active auth backend.
+// The clientset and all executors share it, so they always talk to the same cluster.
+func getRestConfig() *rest.Config {
 	once.Do(func() {
+		restConfigBuilt = true
 		c := config.GetKconsoleConfig()
 		switch c.Auth {
 		case config.LocalConfigAuth:
-			clientSet = defaulClientSet()
+			restConfig = defaultKubeConfig()
 		case config.BcsAuth:
 			// select cluster
-			clusterid := config.GetKconsoleConfig().BCSCluster
-			// clusterid := selectBCSCluster()
-			clientSet = bcsClientSet(clusterid)
+			clusterid := c.BCSCluster
+			if clusterid == "" {
+				errorx.CheckErrorWithCode(fmt.Errorf("no bcs cluster selected, run 'kconsole switch' first"), errorx.ErrorBCSAuthConfigErr)
+			}
+			restConfig = newBcsConfig(clusterid)
 		}
 	})
+	return restConfig
+}
+
+func getClientSet() *kubernetes.Clientset {
+	clientSetOnce.Do(func() {
+		clientset, err := kubernetes.NewForConfig(getRestConfig())
+		errorx.CheckError(err)
+		clientSet = clientset
+	})
 	return clientSet
 }
 
@@ -172,8 +234,13 @@ func selectBCSCluster() (clusterid string) {
 	return selectclusterid
 }
 
-func allPodList() *v1.PodList {
-	pods, err := getClientSet().CoreV1().Pods("").List(context.Background(), metav1.ListOptions{})
+// podList list pods of the namespace, an empty namespace means all namespaces.
+// When listing pods of all namespaces is forbidden, the pods of the accessible namespaces are listed instead.
+func podList(namespace string, opts metav1.ListOptions) *v1.PodList {
+	pods, err := getClientSet().CoreV1().Pods(namespace).List(context.Background(), opts)
+	if namespace == "" && k8serror.IsForbidden(err) {
+		return podListByNamespaces(accessibleNamespaces(), opts)
+	}
 	errorx.CheckError(err)
 
 	return pods
@@ -186,46 +253,252 @@ func getPod(podname string, namespace string) (*v1.Pod, error) {
 	return pod, nil
 }
 
-func ListAllPods() []string {
-	pods := allPodList()
-	var podNames []string
-	for _, pod := range pods.Items {
-		podNames = append(podNames, fmt.Sprintf("%s#%s", pod.Namespace, pod.Name))
+// ListNamespaces list the names of all namespaces, or the accessible ones when listing namespaces is forbidden
+func ListNamespaces() []string {
+	namespaces, err := listNamespaceNames()
+	if k8serror.IsForbidden(err) {
+		return accessibleNamespaces()
 	}
-	return podNames
+	errorx.CheckError(err)
+	return namespaces
 }
 
-func ListContainersByPod(namespace string, podname string) (containers []string) {
-	pod, err := getPod(podname, namespace)
-	errorx.CheckError(err)
+// resolveNamespace return the namespace to list pods in, an empty namespace means all namespaces.
+// Without a namespace in target, the default namespace of the cluster is used, or one is selected
+// when picking a namespace first is enabled.
+func resolveNamespace(target Target) string {
+	if target.Namespace != "" || target.AllNamespaces {
+		return target.Namespace
+	}
+	c := config.GetKconsoleConfig()
+	if ns := c.GetClusterConfig(currentClusterName()).Namespace; ns != "" {
+		return ns
+	}
+	if target.PickNamespace || c.PickNamespace {
+		return SelectUI(ListNamespaces(), "select a namespace")
+	}
+	return ""
+}
 
-	for _, container := range pod.Spec.Containers {
-		containers = append(containers, container.Name)
+// podListOptions return the list options filtering the pods of target
+func podListOptions(target Target) metav1.ListOptions {
+	fieldSelector := target.FieldSelector
+	if target.Pod != "" {
+		fieldSelector = strings.Trim(fieldSelector+",metadata.name="+target.Pod, ",")
+	}
+	return metav1.ListOptions{
+		LabelSelector: target.Selector,
+		FieldSelector: fieldSelector,
+	}
+}
+
+// ListPods list the pods matching target
+func ListPods(target Target) []v1.Pod {
+	return podList(resolveNamespace(target), podListOptions(target)).Items
+}
+
+// targetPods list the pods to select from, the pods of the service or workload when target selects one
+func targetPods(target Target) []v1.Pod {
+	if target.Service != "" {
+		ns, name, err := parseServiceRef(target.Service, resolveNamespace(target))
+		errorx.CheckErrorWithCode(err, errorx.ErrorArgsErr)
+		return ServicePods(ns, name)
+	}
+	if target.byWorkload() {
+		return WorkloadPods(resolveWorkload(target))
+	}
+	return ListPods(target)
+}
+
+// container kinds of ContainerInfo
+const (
+	ContainerKindApp       = "container"
+	ContainerKindInit      = "init"
+	ContainerKindEphemeral = "ephemeral"
+)
+
+// ContainerInfo describe a container of a pod for selection
+type ContainerInfo struct {
+	Name  string
+	Kind  string
+	State string
+}
+
+func (c ContainerInfo) String() string {
+	return fmt.Sprintf("%s (%s, %s)", c.Name, c.Kind, c.State)
+}
+
+// containerState describe the current state of a container, e.g. running, terminated: Error (exit 1)
+func containerState(status *v1.ContainerStatus) string {
+	switch {
+	case status == nil:
+		return "pending"
+	case status.State.Running != nil:
+		return "running"
+	case status.State.Terminated != nil:
+		return fmt.Sprintf("terminated: %s (exit %d)", status.State.Terminated.Reason, status.State.Terminated.ExitCode)
+	case status.State.Waiting != nil:
+		return fmt.Sprintf("waiting: %s", status.State.Waiting.Reason)
+	}
+	return "unknown"
+}
+
+// findContainerStatus find the status of the named container
+func findContainerStatus(statuses []v1.ContainerStatus, name string) *v1.ContainerStatus {
+	for i := range statuses {
+		if statuses[i].Name == name {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+// podContainers list the app, init and ephemeral containers of the pod with their states
+func podContainers(pod *v1.Pod) (containers []ContainerInfo) {
+	for _, c := range pod.Spec.Containers {
+		containers = append(containers, ContainerInfo{
+			Name:  c.Name,
+			Kind:  ContainerKindApp,
+			State: containerState(findContainerStatus(pod.Status.ContainerStatuses, c.Name)),
+		})
+	}
+	for _, c := range pod.Spec.InitContainers {
+		containers = append(containers, ContainerInfo{
+			Name:  c.Name,
+			Kind:  ContainerKindInit,
+			State: containerState(findContainerStatus(pod.Status.InitContainerStatuses, c.Name)),
+		})
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		containers = append(containers, ContainerInfo{
+			Name:  c.Name,
+			Kind:  ContainerKindEphemeral,
+			State: containerState(findContainerStatus(pod.Status.EphemeralContainerStatuses, c.Name)),
+		})
 	}
 	return
 }
 
+// annotationDefaultContainer names the container kubectl uses when none is given
+const annotationDefaultContainer = "kubectl.kubernetes.io/default-container"
+
+// isSidecar report whether the container is one of the sidecars
+func isSidecar(name string, sidecars []string) bool {
+	for _, sidecar := range sidecars {
+		if name == sidecar {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultContainerName return the container to use without asking: the one named by the default-container
+// annotation, or the only app container that is not a sidecar. Empty when the user has to choose.
+func defaultContainerName(pod *v1.Pod, sidecars []string) string {
+	if name := pod.Annotations[annotationDefaultContainer]; name != "" {
+		for _, c := range pod.Spec.Containers {
+			if c.Name == name {
+				return name
+			}
+		}
+	}
+	found := ""
+	for _, c := range pod.Spec.Containers {
+		if isSidecar(c.Name, sidecars) {
+			continue
+		}
+		if found != "" {
+			return ""
+		}
+		found = c.Name
+	}
+	return found
+}
+
+// hasStuckContainers report whether an init or ephemeral container of the pod is waiting or terminated with an
+// error, e.g. a failed init container, which is worth a look instead of the default container
+func hasStuckContainers(pod *v1.Pod) bool {
+	for _, statuses := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.EphemeralContainerStatuses} {
+		for _, status := range statuses {
+			if status.State.Waiting != nil || status.State.Terminated != nil && status.State.Terminated.ExitCode != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// moveContainerFirst move the named container to the top of the menu
+func moveContainerFirst(containers []ContainerInfo, name string) []ContainerInfo {
+	for i, c := range containers {
+		if c.Name == name {
+			result := append([]ContainerInfo{c}, containers[:i]...)
+			return append(result, containers[i+1:]...)
+		}
+	}
+	return containers
+}
+
+// primaryContainerName return the default container of the pod, or its first app container that is not a sidecar
+func primaryContainerName(pod *v1.Pod, sidecars []string) string {
+	if name := defaultContainerName(pod, sidecars); name != "" {
+		return name
+	}
+	for _, c := range pod.Spec.Containers {
+		if !isSidecar(c.Name, sidecars) {
+			return c.Name
+		}
+	}
+	return pod.Spec.Containers[0].Name
+}
+
+// menuContainers list the sidecars after the other containers, or hide them if there are others
+func menuContainers(containers []ContainerInfo, sidecars []string, hide bool) []ContainerInfo {
+	result := make([]ContainerInfo, 0, len(containers))
+	demoted := make([]ContainerInfo, 0)
+	for _, c := range containers {
+		if isSidecar(c.Name, sidecars) {
+			demoted = append(demoted, c)
+		} else {
+			result = append(result, c)
+		}
+	}
+	if hide && len(result) > 0 {
+		return result
+	}
+	return append(result, demoted...)
+}
+
+// ListContainersByPod list all containers of the pod, including init and ephemeral containers
+func ListContainersByPod(namespace string, podname string) []ContainerInfo {
+	pod, err := getPod(podname, namespace)
+	errorx.CheckError(err)
+
+	return podContainers(pod)
+}
+
 // ----
 // ui utils
 // ----
 
 func SelectUI(data []string, title string) string {
-	searcher := func(input string, index int) bool {
-		item := data[index]
-		loweritem := strings.Replace(strings.ToLower(item), " ", "", -1)
-		return strings.Contains(loweritem, input)
-	}
+	return data[SelectIndexUI(data, title)]
+}
 
+// SelectIndexUI select an item and return its index in data, searching ranks the items by fuzzy score
+func SelectIndexUI(data []string, title string) int {
 	prompt := promptui.Select{
-		Label:    title,
-		Items:    data,
-		Searcher: searcher,
+		Label: title,
+	}
+	items := make([]interface{}, 0, len(data))
+	for _, item := range data {
+		items = append(items, item)
 	}
 
-	_, result, err := prompt.Run()
+	index, err := runRankedSelect(prompt, items, data)
 	errorx.CheckErrorWithCode(err, errorx.ErrorSelectExit)
 
-	return result
+	return index
 }
 
 func InputUI(title string, prefix string, defaultStr string) string {
@@ -253,55 +526,208 @@ func InputUI(title string, prefix string, defaultStr string) string {
 	return result
 }
 
-// SelectPodNs select a podname & namespace
-func SelectPodNs() (pod, ns string) {
-	pods := ListAllPods()
-	selectpod := SelectUI(pods, "select a pod")
-	// pod: namespace/podname
-	namespace_pod := strings.Split(selectpod, "#")
-	ns = namespace_pod[0]
-	pod = namespace_pod[1]
-	return
+// flagOrInputUI return the value of the flag, prompting with InputUI when it is not set
+func flagOrInputUI(cmd *cobra.Command, flag string, title string, prefix string, defaultStr string) string {
+	val, err := cmd.Flags().GetString(flag)
+	errorx.CheckError(err)
+	if val != "" {
+		return val
+	}
+	return InputUI(title, prefix, defaultStr)
 }
 
-// SelectContainer select a cnotainer from pod->container
-func SelectContainer() (pod, ns, container string) {
-	pod, ns = SelectPodNs()
-	containers := ListContainersByPod(ns, pod)
-	container = SelectUI(containers, "select a container")
-	return
+// SelectPodNs select a podname & namespace, the parts given by target are not prompted
+func SelectPodNs(target Target) (pod, ns string) {
+	if target.Namespace != "" && target.Pod != "" {
+		return target.Pod, target.Namespace
+	}
+	pods := targetPods(target)
+	if len(pods) == 0 {
+		errorx.CheckErrorWithCode(fmt.Errorf("no pod matches namespace=%q pod=%q selector=%q field-selector=%q",
+			target.Namespace, target.Pod, target.Selector, target.FieldSelector), errorx.ErrorArgsErr)
+	}
+	if target.AutoPick {
+		if ready := pickReadyPod(pods); ready != nil {
+			return ready.Name, ready.Namespace
+		}
+	}
+	if target.Pod != "" && len(pods) == 1 {
+		return pods[0].Name, pods[0].Namespace
+	}
+	pods, marks := sortPodsByHistory(pods, clusterHistory())
+	return selectPodUI(pods, marks, "select a pod")
+}
+
+// SelectContainer select a cnotainer from pod->container, the parts given by target are not prompted
+func SelectContainer(target Target) (pod, ns, container string) {
+	p, container := selectPodContainer(target)
+	return p.Name, p.Namespace, container
+}
+
+// selectPodContainer select the pod and its container. The only container, the default container and
+// the only app container besides sidecars are used without asking, unless target.AllContainers.
+// In the menu the containers used before are listed first and the sidecars last.
+func selectPodContainer(target Target) (*v1.Pod, string) {
+	podname, ns := SelectPodNs(target)
+	pod, err := getPod(podname, ns)
+	errorx.CheckError(err)
+	containers := podContainers(pod)
+	if target.Container == "" {
+		if len(containers) == 1 {
+			return pod, containers[0].Name
+		}
+		defaultName := ""
+		if !target.AllContainers {
+			c := config.GetKconsoleConfig()
+			defaultName = defaultContainerName(pod, c.SidecarNames())
+			// a stuck init or ephemeral container is offered instead of being hidden behind the default one
+			if defaultName != "" && !hasStuckContainers(pod) {
+				return pod, defaultName
+			}
+			containers = menuContainers(containers, c.SidecarNames(), c.HideSidecars)
+		}
+		containers = sortContainersByHistory(pod, containers, clusterHistory())
+		containers = moveContainerFirst(containers, defaultName)
+		labels := make([]string, 0, len(containers))
+		for _, c := range containers {
+			labels = append(labels, c.String())
+		}
+		return pod, containers[SelectIndexUI(labels, "select a container")].Name
+	}
+	for _, c := range containers {
+		if c.Name == target.Container {
+			return pod, c.Name
+		}
+	}
+	errorx.CheckErrorWithCode(fmt.Errorf("container %q not found in pod %s/%s", target.Container, ns, podname), errorx.ErrorArgsErr)
+	return pod, ""
 }
 
 // ---
 // exec utils
 // ---
 
-func ExecPodContainer(namespace string, pod string, container string, command string) error {
-	clientset := getClientSet()
-	req := clientset.CoreV1().RESTClient().Post().
+// newSubresourceExecutor build an executor of the pod's subresource with the shared rest config
+func newSubresourceExecutor(namespace string, pod string, subresource string, opts runtime.Object) (remotecommand.Executor, error) {
+	req := getClientSet().CoreV1().RESTClient().Post().
 		Resource("pods").
 		Namespace(namespace).
 		Name(pod).
-		SubResource("exec").
-		VersionedParams(&v1.PodExecOptions{
+		SubResource(subresource).
+		VersionedParams(opts, scheme.ParameterCodec)
+	return remotecommand.NewSPDYExecutor(getRestConfig(), http.MethodPost, req.URL())
+}
+
+// newExecutor build an executor of the pod's exec subresource
+func newExecutor(namespace string, pod string, opts *v1.PodExecOptions) (remotecommand.Executor, error) {
+	return newSubresourceExecutor(namespace, pod, "exec", opts)
+}
+
+// newAttachExecutor build an executor of the pod's attach subresource
+func newAttachExecutor(namespace string, pod string, opts *v1.PodAttachOptions) (remotecommand.Executor, error) {
+	return newSubresourceExecutor(namespace, pod, "attach", opts)
+}
+
+// execCommand run a command in the container without a TTY, nil streams are not attached
+func execCommand(namespace string, pod string, container string, command []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	executor, err := newExecutor(namespace, pod, &v1.PodExecOptions{
+		Container: container,
+		Command:   command,
+		Stdin:     stdin != nil,
+		Stdout:    stdout != nil,
+		Stderr:    stderr != nil,
+		TTY:       false,
+	})
+	if err != nil {
+		return err
+	}
+	return executor.StreamWithContext(context.Background(), remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+}
+
+// DetectShell return the first shell of the candidates which can be run in the container
+func DetectShell(namespace string, pod string, container string, candidates []string) ([]string, error) {
+	shell, err := detectShell(candidates, func(probe []string) error {
+		return execCommand(namespace, pod, container, probe, nil, io.Discard, io.Discard)
+	})
+	if err == nil && shell == nil {
+		err = fmt.Errorf("no shell found in container %s of pod %s/%s, tried %s", container, namespace, pod, strings.Join(candidates, ", "))
+	}
+	return shell, err
+}
+
+// detectShell probe the candidates in turn, returning the first shell that runs, or nil if none does.
+// Only a shell that is missing moves on to the next one, other errors, e.g. forbidden or a stopped
+// container, are returned as they are.
+func detectShell(candidates []string, run func(probe []string) error) ([]string, error) {
+	for _, candidate := range candidates {
+		shell := strings.Fields(candidate)
+		if len(shell) == 0 {
+			continue
+		}
+		probe := append(append([]string{}, shell...), "-c", "exit 0")
+		err := run(probe)
+		if err == nil {
+			return shell, nil
+		}
+		if !isShellMissing(err) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// isShellMissing report whether the probe of a shell failed because the shell can not run in the container:
+// it exits with a non-zero code, or the runtime does not find the executable
+func isShellMissing(err error) bool {
+	var exitErr exec.ExitError
+	if stderrors.As(err, &exitErr) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "executable file not found") || strings.Contains(msg, "no such file or directory")
+}
+
+// RunPodCommand run a command in the container, a TTY is allocated when stdin and stdout are terminals.
+// When the command exits with a non-zero code, the returned error is an exec.ExitError carrying it.
+func RunPodCommand(namespace string, pod string, container string, command []string) error {
+	if term.IsTerminal(os.Stdin) && term.IsTerminal(os.Stdout) {
+		executor, err := newExecutor(namespace, pod, &v1.PodExecOptions{
 			Container: container,
-			Command:   []string{command},
+			Command:   command,
 			Stdin:     true,
 			Stdout:    true,
-			Stderr:    true,
 			TTY:       true,
-		}, scheme.ParameterCodec)
+		})
+		if err != nil {
+			return err
+		}
+		return streamTTY(context.Background(), executor)
+	}
+	// forward stdin only when it is piped, a terminal would block the command waiting for input
+	var stdin io.Reader
+	if !term.IsTerminal(os.Stdin) {
+		stdin = os.Stdin
+	}
+	return execCommand(namespace, pod, container, command, stdin, os.Stdout, os.Stderr)
+}
 
+func ExecPodContainer(namespace string, pod string, container string, command []string) error {
 	// 创建执行器
-	executor, err := remotecommand.NewSPDYExecutor(defaultKubeConfig(), http.MethodPost, req.URL())
+	executor, err := newExecutor(namespace, pod, &v1.PodExecOptions{
+		Container: container,
+		Command:   command,
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    false,
+		TTY:       true,
+	})
 	errorx.CheckError(err)
 
-	err = executor.StreamWithContext(context.Background(), remotecommand.StreamOptions{
-		Stdin:  os.Stdin,
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
-		Tty:    true,
-	})
+	err = streamTTY(context.Background(), executor)
 	if err != nil {
 		if k8serror.IsNotFound(err) {
 			fmt.Printf("Pod %s/%s not found\n", namespace, pod)
@@ -315,25 +741,17 @@ func ExecPodContainer(namespace string, pod string, container string, command st
 }
 
 func copyFromPod(namespace string, pod string, container string, srcPath string, destPath string) error {
-	clientset := getClientSet()
 	reader, outStream := io.Pipe()
 	//todo some containers failed : tar: Refusing to write archive contents to terminal (missing -f option?) when execute `tar cf -` in container
 	cmdArr := []string{"tar", "cf", "-", srcPath}
-	req := clientset.CoreV1().RESTClient().
-		Get().
-		Namespace(namespace).
-		Resource("pods").
-		Name(pod).
-		SubResource("exec").
-		VersionedParams(&v1.PodExecOptions{
-			Container: container,
-			Command:   cmdArr,
-			Stdin:     true,
-			Stdout:    true,
-			Stderr:    true,
-			TTY:       false,
-		}, scheme.ParameterCodec)
-	exec, err := remotecommand.NewSPDYExecutor(defaultKubeConfig(), http.MethodPost, req.URL())
+	exec, err := newExecutor(namespace, pod, &v1.PodExecOptions{
+		Container: container,
+		Command:   cmdArr,
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       false,
+	})
 	if err != nil {
 		log.Fatalf("error %s\n", err)
 		return err
@@ -357,28 +775,20 @@ func copyFromPod(namespace string, pod string, container string, srcPath string,
 }
 
 func copyToPod(namespace string, pod string, container string, srcPath string, destPath string) error {
-	clientset := getClientSet()
 	reader, writer := io.Pipe()
 	go func() {
 		defer writer.Close()
 		cmdutil.CheckErr(makeTar(srcPath, destPath, writer))
 	}()
 
-	req := clientset.CoreV1().RESTClient().Post().
-		Resource("pods").
-		Name(pod).
-		Namespace(namespace).
-		SubResource("exec").
-		VersionedParams(&v1.PodExecOptions{
-			Command:   []string{"tar", "-xmf", "-"},
-			Container: container,
-			Stdin:     true,
-			Stdout:    true,
-			Stderr:    true,
-			TTY:       false,
-		}, scheme.ParameterCodec)
-
-	exec, err := remotecommand.NewSPDYExecutor(defaultKubeConfig(), http.MethodPost, req.URL())
+	exec, err := newExecutor(namespace, pod, &v1.PodExecOptions{
+		Command:   []string{"tar", "-xmf", "-"},
+		Container: container,
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       false,
+	})
 	if err != nil {
 		return err
 	}
@@ -562,49 +972,50 @@ func recursiveTar(srcBase, srcFile, destBase, destFile string, tw *tar.Writer) e
 	return nil
 }
 
-func getLog(namespace, podname, container string, lines int64) (io.ReadCloser, error) {
+func getLog(ctx context.Context, namespace, podname string, opts *v1.PodLogOptions) (io.ReadCloser, error) {
 	clientset := getClientSet()
-	ctx := context.Background()
+	resp := clientset.CoreV1().Pods(namespace).GetLogs(podname, opts)
+	lr, err := resp.Stream(ctx)
+	return lr, err
+}
+
+// newLogOptions return the options reading the last lines of the container's logs, all of them if lines is -1
+func newLogOptions(container string, lines int64) *v1.PodLogOptions {
 	opts := &v1.PodLogOptions{
 		Container: container,
 	}
 	if lines != -1 {
 		opts.TailLines = &lines
 	}
-	resp := clientset.CoreV1().Pods(namespace).GetLogs(podname, opts)
-	lr, err := resp.Stream(ctx)
-	return lr, err
+	return opts
 }
 
-// PrintLogs print container's logs to stdout
-func PrintLogs(namespace, podname, container string, lines int64) error {
-	lr, err := getLog(namespace, podname, container, lines)
-	errorx.CheckError(err)
-	printBuffer(lr)
-	return nil
-}
-
-// SaveLogs save container's logs to files
-func SaveLogs(namespace, podname, container string, filename string) error {
-	lr, err := getLog(namespace, podname, container, -1)
-	errorx.CheckError(err)
-	saveBuffer2file(lr, filename)
-	return nil
+// PrintLogs print container's logs to stdout as they arrive, rendered unless render is nil. When opts.Follow
+// the logs are followed until interrupted, and with reconnect the stream is reopened after the container restarts.
+func PrintLogs(namespace, podname string, opts *v1.PodLogOptions, render *logRenderOptions, reconnect bool) error {
+	if render == nil {
+		return writeLogs(namespace, podname, opts, os.Stdout, reconnect)
+	}
+	out := render.newWriter(os.Stdout)
+	err := writeLogs(namespace, podname, opts, out, reconnect)
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
 }
 
-func printBuffer(data io.ReadCloser) {
-	buf := new(bytes.Buffer)
-	_, err := buf.ReadFrom(data)
-	errorx.CheckErrorWithIgnore(err, []error{io.EOF})
-	log.Print(buf.String())
+// SaveLogs stream container's logs to the file as they arrive, following them like PrintLogs
+func SaveLogs(namespace, podname string, opts *v1.PodLogOptions, file *logFile, reconnect bool) error {
+	err := writeLogs(namespace, podname, opts, file, reconnect)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
 }
 
-func saveBuffer2file(data io.ReadCloser, filename string) {
-	buf := new(bytes.Buffer)
-	_, err := buf.ReadFrom(data)
-	errorx.CheckErrorWithIgnore(err, []error{io.EOF})
-	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600)
-	errorx.CheckError(err)
-	_, err = f.Write(buf.Bytes())
-	errorx.CheckError(err)
+// writeLogs stream container's logs to out until they end or the user interrupts
+func writeLogs(namespace, podname string, opts *v1.PodLogOptions, out io.Writer, reconnect bool) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return followLogs(ctx, namespace, podname, opts, out, reconnect)
 }
@@ -22,12 +22,138 @@
 package cmd
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/exec"
 )
 
 func TestPrintLogo(t *testing.T) {
 	logo := PrintLogo()
 	assert.Contains(t, logo, "Exec your container more easily.")
 }
+
+func TestPodContainers(t *testing.T) {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "migrate"}},
+			Containers:     []v1.Container{{Name: "app"}},
+			EphemeralContainers: []v1.EphemeralContainer{
+				{EphemeralContainerCommon: v1.EphemeralContainerCommon{Name: "debugger"}},
+			},
+		},
+		Status: v1.PodStatus{
+			InitContainerStatuses: []v1.ContainerStatus{{
+				Name:  "migrate",
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}},
+			}},
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name:  "app",
+				State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "PodInitializing"}},
+			}},
+		},
+	}
+	assert.Equal(t, []ContainerInfo{
+		{Name: "app", Kind: ContainerKindApp, State: "waiting: PodInitializing"},
+		{Name: "migrate", Kind: ContainerKindInit, State: "terminated: Error (exit 1)"},
+		{Name: "debugger", Kind: ContainerKindEphemeral, State: "pending"},
+	}, podContainers(pod))
+}
+
+func TestPodListOptions(t *testing.T) {
+	opts := podListOptions(Target{Selector: "app=api"})
+	assert.Equal(t, "app=api", opts.LabelSelector)
+	assert.Equal(t, "", opts.FieldSelector)
+
+	opts = podListOptions(Target{Pod: "api-0", FieldSelector: "status.phase=Running"})
+	assert.Equal(t, "status.phase=Running,metadata.name=api-0", opts.FieldSelector)
+}
+
+func TestDefaultContainerName(t *testing.T) {
+	sidecars := []string{"istio-proxy", "log-agent"}
+	pod := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{Name: "istio-proxy"}, {Name: "app"}, {Name: "log-agent"}}}}
+	assert.Equal(t, "app", defaultContainerName(pod, sidecars))
+	assert.Equal(t, "", defaultContainerName(pod, nil))
+	assert.Equal(t, "istio-proxy", primaryContainerName(pod, nil))
+
+	pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: "worker"})
+	assert.Equal(t, "", defaultContainerName(pod, sidecars))
+	assert.Equal(t, "app", primaryContainerName(pod, sidecars))
+
+	pod.Annotations = map[string]string{annotationDefaultContainer: "worker"}
+	assert.Equal(t, "worker", defaultContainerName(pod, sidecars))
+	// an annotation naming a missing container is ignored
+	pod.Annotations[annotationDefaultContainer] = "gone"
+	assert.Equal(t, "", defaultContainerName(pod, sidecars))
+}
+
+func TestMenuContainers(t *testing.T) {
+	sidecars := []string{"istio-proxy"}
+	containers := []ContainerInfo{{Name: "istio-proxy"}, {Name: "app"}, {Name: "worker"}}
+	assert.Equal(t, []ContainerInfo{{Name: "app"}, {Name: "worker"}, {Name: "istio-proxy"}}, menuContainers(containers, sidecars, false))
+	assert.Equal(t, []ContainerInfo{{Name: "app"}, {Name: "worker"}}, menuContainers(containers, sidecars, true))
+	// sidecars are not hidden when there is nothing else
+	assert.Equal(t, []ContainerInfo{{Name: "istio-proxy"}}, menuContainers(containers[:1], sidecars, true))
+}
+
+func TestHasStuckContainers(t *testing.T) {
+	pod := &v1.Pod{Status: v1.PodStatus{
+		InitContainerStatuses: []v1.ContainerStatus{
+			{Name: "migrate", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0}}},
+		},
+		EphemeralContainerStatuses: []v1.ContainerStatus{
+			{Name: "debugger", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+		},
+	}}
+	assert.False(t, hasStuckContainers(pod))
+
+	pod.Status.InitContainerStatuses[0].State = v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 1}}
+	assert.True(t, hasStuckContainers(pod))
+
+	pod.Status.InitContainerStatuses[0].State = v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+	assert.True(t, hasStuckContainers(pod))
+
+	pod.Status.InitContainerStatuses = nil
+	pod.Status.EphemeralContainerStatuses[0].State = v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}
+	assert.True(t, hasStuckContainers(pod))
+}
+
+func TestMoveContainerFirst(t *testing.T) {
+	containers := []ContainerInfo{{Name: "init"}, {Name: "worker"}, {Name: "app"}}
+	assert.Equal(t, []ContainerInfo{{Name: "app"}, {Name: "init"}, {Name: "worker"}}, moveContainerFirst(containers, "app"))
+	assert.Equal(t, containers, moveContainerFirst(containers, ""))
+}
+
+func TestDetectShell(t *testing.T) {
+	var probed []string
+	probe := func(errs map[string]error) func([]string) error {
+		probed = nil
+		return func(cmd []string) error {
+			probed = append(probed, cmd[0])
+			return errs[cmd[0]]
+		}
+	}
+
+	// missing shells move on to the next candidate
+	shell, err := detectShell([]string{"zsh", "bash -l", "sh"}, probe(map[string]error{
+		"zsh":  exec.CodeExitError{Err: fmt.Errorf("command terminated with exit code 127"), Code: 127},
+		"bash": fmt.Errorf(`exec: "bash": executable file not found in $PATH: unknown`),
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sh"}, shell)
+	assert.Equal(t, []string{"zsh", "bash", "sh"}, probed)
+
+	// other errors are returned as they are instead of trying the next shell
+	forbidden := k8serror.NewForbidden(v1.Resource("pods/exec"), "api-0", nil)
+	_, err = detectShell([]string{"bash", "sh"}, probe(map[string]error{"bash": forbidden}))
+	assert.Equal(t, forbidden, err)
+	assert.Equal(t, []string{"bash"}, probed)
+
+	// no shell at all
+	shell, err = detectShell([]string{"bash"}, probe(map[string]error{"bash": exec.CodeExitError{Code: 126}}))
+	assert.NoError(t, err)
+	assert.Nil(t, shell)
+}
@@ -0,0 +1,704 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/pterm/pterm"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubectl/pkg/util/term"
+)
+
+const (
+	// maxViewerLines the number of lines kept by the viewer at least, the oldest are dropped beyond it
+	maxViewerLines = 100000
+	// viewerRefreshInterval the interval of redrawing the viewer for new lines and size changes
+	viewerRefreshInterval = 100 * time.Millisecond
+	// viewerTabWidth the spaces a tab is expanded to
+	viewerTabWidth = 4
+)
+
+// the keys of the viewer that are not a single printable character
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyLeft      = "left"
+	keyRight     = "right"
+	keyPageUp    = "pgup"
+	keyPageDown  = "pgdown"
+	keyHome      = "home"
+	keyEnd       = "end"
+	keyEnter     = "enter"
+	keyEscape    = "esc"
+	keyBackspace = "backspace"
+	keyCtrlC     = "ctrl-c"
+)
+
+// the escape sequences of the keys of the viewer
+var viewerKeySequences = map[string]string{
+	"\x1b[A": keyUp, "\x1b[B": keyDown, "\x1b[C": keyRight, "\x1b[D": keyLeft,
+	"\x1bOA": keyUp, "\x1bOB": keyDown, "\x1bOC": keyRight, "\x1bOD": keyLeft,
+	"\x1b[5~": keyPageUp, "\x1b[6~": keyPageDown,
+	"\x1b[H": keyHome, "\x1b[1~": keyHome, "\x1b[7~": keyHome, "\x1bOH": keyHome,
+	"\x1b[F": keyEnd, "\x1b[4~": keyEnd, "\x1b[8~": keyEnd, "\x1bOF": keyEnd,
+}
+
+// the help shown in the status bar
+const viewerHelp = "q quit  / search  n/N next/prev  w wrap  t time  f follow"
+
+// ansiPattern match the escape sequences of colours and other terminal controls
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[@-~]`)
+
+// parseViewerKeys split the bytes read from a raw terminal into keys. Printable characters are keys of their own,
+// a lone escape is the escape key and unknown escape sequences are dropped.
+func parseViewerKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		switch {
+		case b[0] == 0x1b:
+			n := escapeSequenceLength(string(b))
+			if n == 1 {
+				keys = append(keys, keyEscape)
+			} else if key, ok := viewerKeySequences[string(b[:n])]; ok {
+				keys = append(keys, key)
+			}
+			b = b[n:]
+			continue
+		case b[0] == '\r' || b[0] == '\n':
+			keys = append(keys, keyEnter)
+		case b[0] == 0x7f || b[0] == 0x08:
+			keys = append(keys, keyBackspace)
+		case b[0] == 0x03:
+			keys = append(keys, keyCtrlC)
+		case b[0] < 0x20:
+			// other control characters are not keys of the viewer
+		default:
+			r, n := utf8.DecodeRune(b)
+			keys = append(keys, string(r))
+			b = b[n:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// escapeSequenceLength return the length of the escape sequence at the start of b, 1 for a lone escape
+func escapeSequenceLength(b string) int {
+	if len(b) < 2 {
+		return 1
+	}
+	switch b[1] {
+	case 'O':
+		if len(b) < 3 {
+			return 2
+		}
+		return 3
+	case '[':
+		for i := 2; i < len(b); i++ {
+			if b[i] >= 0x40 && b[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(b)
+	}
+	return 1
+}
+
+// cutANSI cut the text after width columns, escape sequences take no column. The escape sequences of the head
+// are repeated in front of the rest, so a colour carries on over the cut. At least one character is kept
+// in the head, even if it is wider than width.
+func cutANSI(s string, width int) (head, rest string) {
+	var escapes strings.Builder
+	cols, i := 0, 0
+	for i < len(s) {
+		if s[i] == 0x1b {
+			n := escapeSequenceLength(s[i:])
+			escapes.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(s[i:])
+		w := runewidth.RuneWidth(r)
+		if cols+w > width && cols > 0 {
+			break
+		}
+		cols += w
+		i += n
+	}
+	if i >= len(s) {
+		return s, ""
+	}
+	return s[:i], escapes.String() + s[i:]
+}
+
+// stripANSI remove the escape sequences of the text
+func stripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+// viewerLine a line of the log in the viewer
+type viewerLine struct {
+	// time the timestamp of the line added by kubelet
+	time string
+	// text the rendered line, it may be coloured
+	text string
+	// plain the rendered line without colours, searched
+	plain string
+}
+
+// viewPos a row of the screen, the row of the wrapped line
+type viewPos struct {
+	line int
+	row  int
+}
+
+func (p viewPos) before(o viewPos) bool {
+	return p.line < o.line || p.line == o.line && p.row < o.row
+}
+
+// logViewer a full-screen pager of a log stream. Written lines are kept and shown from the top position,
+// in follow mode the view sticks to the end of the log, paused it stays where it is.
+type logViewer struct {
+	title  string
+	render *logRenderOptions
+
+	mu        sync.Mutex
+	lines     []viewerLine
+	splitter  *lineWriter
+	lastShown bool
+	dirty     bool
+
+	width, height int
+	top           viewPos
+	follow        bool
+	wrap          bool
+	timestamps    bool
+	search        *regexp.Regexp
+	prompting     bool
+	input         []rune
+	status        string
+}
+
+func newLogViewer(title string, render *logRenderOptions, follow, timestamps bool) *logViewer {
+	v := &logViewer{
+		title:      title,
+		render:     render,
+		lastShown:  true,
+		follow:     follow,
+		wrap:       true,
+		timestamps: timestamps,
+		width:      80,
+		height:     24,
+	}
+	v.splitter = newLineWriter(func(line []byte) error {
+		v.appendLine(string(bytes.TrimSuffix(line, []byte("\n"))))
+		return nil
+	})
+	return v
+}
+
+// Write add the complete lines to the viewer, the log is expected to be prefixed by kubelet timestamps
+func (v *logViewer) Write(p []byte) (int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.splitter.Write(p)
+}
+
+// Flush add the last line even if it is not terminated by a newline
+func (v *logViewer) Flush() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.splitter.Flush()
+}
+
+// setStatus show a message in the status bar until the next key
+func (v *logViewer) setStatus(msg string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.status = msg
+	v.dirty = true
+}
+
+func (v *logViewer) appendLine(raw string) {
+	raw = strings.TrimSuffix(raw, "\r")
+	_, stamp, body, _ := splitTimestamp(raw)
+	if v.render != nil {
+		rendered, shown, isEntry := v.render.render(body, v.lastShown)
+		if isEntry {
+			v.lastShown = shown
+		}
+		if !shown {
+			return
+		}
+		body = rendered
+	}
+	body = strings.ReplaceAll(body, "\t", strings.Repeat(" ", viewerTabWidth))
+	v.lines = append(v.lines, viewerLine{time: stamp, text: body, plain: stripANSI(body)})
+	// the oldest lines are dropped a tenth at a time rather than copying the lines for every new one
+	if len(v.lines) > maxViewerLines+maxViewerLines/10 {
+		dropped := len(v.lines) - maxViewerLines
+		v.lines = append(v.lines[:0:0], v.lines[dropped:]...)
+		v.top.line -= dropped
+		if v.top.line < 0 {
+			v.top = viewPos{}
+		}
+	}
+	if v.follow {
+		v.top = v.bottom()
+	}
+	v.dirty = true
+}
+
+// pageHeight the rows showing the log, the last row is the status bar
+func (v *logViewer) pageHeight() int {
+	if v.height < 2 {
+		return 1
+	}
+	return v.height - 1
+}
+
+// matchText the text of the line searched, as it is shown
+func (v *logViewer) matchText(i int) string {
+	l := v.lines[i]
+	if v.timestamps && l.time != "" {
+		return l.time + " " + l.plain
+	}
+	return l.plain
+}
+
+// display the line as it is shown, the matches of the search are highlighted instead of the colours of the line
+func (v *logViewer) display(i int) string {
+	l := v.lines[i]
+	stamp := ""
+	if v.timestamps && l.time != "" {
+		stamp = pterm.FgGray.Sprint(l.time) + " "
+	}
+	if v.search == nil {
+		return stamp + l.text
+	}
+	text := v.matchText(i)
+	matches := v.search.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return stamp + l.text
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m[0] == m[1] {
+			continue
+		}
+		b.WriteString(text[last:m[0]])
+		b.WriteString("\x1b[7m" + text[m[0]:m[1]] + "\x1b[27m")
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// rows the rows of the screen showing the line, one cut at the width unless wrapping
+func (v *logViewer) rows(i int) []string {
+	s := v.display(i)
+	width := v.width
+	if width < 1 {
+		width = 1
+	}
+	if !v.wrap {
+		head, _ := cutANSI(s, width)
+		return []string{head}
+	}
+	var rows []string
+	for {
+		head, rest := cutANSI(s, width)
+		rows = append(rows, head)
+		if rest == "" || stripANSI(rest) == "" {
+			return rows
+		}
+		s = rest
+	}
+}
+
+func (v *logViewer) next(p viewPos) (viewPos, bool) {
+	if p.row+1 < len(v.rows(p.line)) {
+		return viewPos{p.line, p.row + 1}, true
+	}
+	if p.line+1 < len(v.lines) {
+		return viewPos{p.line + 1, 0}, true
+	}
+	return p, false
+}
+
+func (v *logViewer) prev(p viewPos) (viewPos, bool) {
+	if p.row > 0 {
+		return viewPos{p.line, p.row - 1}, true
+	}
+	if p.line > 0 {
+		return viewPos{p.line - 1, len(v.rows(p.line-1)) - 1}, true
+	}
+	return p, false
+}
+
+// bottom the top position showing the end of the log on the last page
+func (v *logViewer) bottom() viewPos {
+	if len(v.lines) == 0 {
+		return viewPos{}
+	}
+	last := len(v.lines) - 1
+	p := viewPos{last, len(v.rows(last)) - 1}
+	for n := 1; n < v.pageHeight(); n++ {
+		q, ok := v.prev(p)
+		if !ok {
+			break
+		}
+		p = q
+	}
+	return p
+}
+
+// clamp keep the top position in the log after it changed, e.g. by a new width or by unwrapping
+func (v *logViewer) clamp() {
+	if len(v.lines) == 0 {
+		v.top = viewPos{}
+		return
+	}
+	if v.top.line >= len(v.lines) {
+		v.top = viewPos{len(v.lines) - 1, 0}
+	}
+	if rows := len(v.rows(v.top.line)); v.top.row >= rows {
+		v.top.row = rows - 1
+	}
+	if bottom := v.bottom(); v.follow || bottom.before(v.top) {
+		v.top = bottom
+	}
+}
+
+// scroll move the view by n rows, up if negative. Scrolling pauses following.
+func (v *logViewer) scroll(n int) {
+	v.follow = false
+	bottom := v.bottom()
+	for ; n > 0 && v.top.before(bottom); n-- {
+		v.top, _ = v.next(v.top)
+	}
+	for ; n < 0; n++ {
+		p, ok := v.prev(v.top)
+		if !ok {
+			break
+		}
+		v.top = p
+	}
+}
+
+// findMatch move the view to the next line matching the search, backwards if not forward, wrapping around
+func (v *logViewer) findMatch(forward bool) {
+	if v.search == nil || len(v.lines) == 0 {
+		return
+	}
+	step := 1
+	if !forward {
+		step = -1
+	}
+	n := len(v.lines)
+	for k := 1; k <= n; k++ {
+		i := ((v.top.line+step*k)%n + n) % n
+		if !v.search.MatchString(v.matchText(i)) {
+			continue
+		}
+		if forward && i <= v.top.line {
+			v.status = "search hit BOTTOM, continuing at TOP"
+		} else if !forward && i >= v.top.line {
+			v.status = "search hit TOP, continuing at BOTTOM"
+		}
+		v.follow = false
+		v.top = viewPos{i, 0}
+		v.clamp()
+		return
+	}
+	v.status = "pattern not found: " + v.search.String()
+}
+
+// applySearch search the pattern typed, an empty pattern repeats the last search
+func (v *logViewer) applySearch(pattern string) {
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			v.status = fmt.Sprintf("invalid pattern: %v", err)
+			return
+		}
+		v.search = re
+	}
+	// the top line itself is the first candidate
+	if v.search != nil && len(v.lines) > 0 && v.search.MatchString(v.matchText(v.top.line)) {
+		v.follow = false
+		v.top.row = 0
+		v.clamp()
+		return
+	}
+	v.findMatch(true)
+}
+
+// handleKey act on a key, reporting whether the viewer is quit
+func (v *logViewer) handleKey(key string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.dirty = true
+	if v.prompting {
+		switch key {
+		case keyEnter:
+			v.prompting = false
+			v.applySearch(string(v.input))
+		case keyEscape, keyCtrlC:
+			v.prompting = false
+		case keyBackspace:
+			if len(v.input) > 0 {
+				v.input = v.input[:len(v.input)-1]
+			}
+		default:
+			if utf8.RuneCountInString(key) == 1 {
+				v.input = append(v.input, []rune(key)...)
+			}
+		}
+		return false
+	}
+	v.status = ""
+	page := v.pageHeight()
+	switch key {
+	case "q", "Q", keyCtrlC:
+		return true
+	case "j", keyDown, keyEnter:
+		v.scroll(1)
+	case "k", keyUp:
+		v.scroll(-1)
+	case " ", keyPageDown:
+		v.scroll(page)
+	case "b", keyPageUp:
+		v.scroll(-page)
+	case "d":
+		v.scroll(page / 2)
+	case "u":
+		v.scroll(-page / 2)
+	case "g", keyHome:
+		v.follow = false
+		v.top = viewPos{}
+	case "G", keyEnd:
+		v.follow = false
+		v.top = v.bottom()
+	case "f", "F":
+		v.follow = !v.follow
+		v.clamp()
+	case "w":
+		v.wrap = !v.wrap
+		v.clamp()
+	case "t":
+		v.timestamps = !v.timestamps
+		v.clamp()
+	case "/":
+		v.prompting = true
+		v.input = nil
+	case "n":
+		v.findMatch(true)
+	case "N":
+		v.findMatch(false)
+	case keyEscape:
+		v.search = nil
+	}
+	return false
+}
+
+// statusBar the last row of the screen: the mode, the position and the help, or the search prompt
+func (v *logViewer) statusBar() string {
+	if v.prompting {
+		return "/" + string(v.input)
+	}
+	mode := "PAUSED"
+	if v.follow {
+		mode = "FOLLOW"
+	}
+	bar := fmt.Sprintf(" %s  %s  %d/%d", v.title, mode, v.top.line+1, len(v.lines))
+	if len(v.lines) == 0 {
+		bar = fmt.Sprintf(" %s  %s  no log yet", v.title, mode)
+	}
+	if v.search != nil {
+		bar += "  /" + v.search.String()
+	}
+	if v.status != "" {
+		bar += "  " + v.status
+	}
+	if runewidth.StringWidth(bar)+runewidth.StringWidth(viewerHelp)+4 <= v.width {
+		bar += strings.Repeat(" ", v.width-runewidth.StringWidth(bar)-runewidth.StringWidth(viewerHelp)-1) + viewerHelp + " "
+	}
+	bar, _ = cutANSI(bar, v.width)
+	if pad := v.width - runewidth.StringWidth(bar); pad > 0 {
+		bar += strings.Repeat(" ", pad)
+	}
+	return "\x1b[7m" + bar + "\x1b[0m"
+}
+
+// draw write the screen to out at the given size
+func (v *logViewer) draw(out io.Writer, width, height int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.width, v.height = width, height
+	v.clamp()
+	v.dirty = false
+
+	var b strings.Builder
+	b.WriteString("\x1b[?25l\x1b[H")
+	p, ok := v.top, len(v.lines) > 0
+	for n := 0; n < v.pageHeight(); n++ {
+		if ok {
+			b.WriteString(v.rows(p.line)[p.row])
+			p, ok = v.next(p)
+		} else {
+			b.WriteString(pterm.FgGray.Sprint("~"))
+		}
+		b.WriteString("\x1b[0m\x1b[K\r\n")
+	}
+	b.WriteString(v.statusBar())
+	b.WriteString("\x1b[K")
+	if v.prompting {
+		b.WriteString("\x1b[?25h")
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// viewerNotice show the notices of the log stream, e.g. reconnecting, in the status bar of the viewer
+type viewerNotice struct {
+	viewer *logViewer
+}
+
+func (n viewerNotice) Write(p []byte) (int, error) {
+	n.viewer.setStatus(strings.TrimSpace(stripANSI(string(p))))
+	return len(p), nil
+}
+
+// ViewLogs page through the container's logs in a full-screen viewer until it is quit. The log keeps streaming,
+// with follow the view starts following its end, otherwise it starts paused at the top.
+func ViewLogs(namespace, podname string, opts *v1.PodLogOptions, render *logRenderOptions, reconnect bool) error {
+	tty := term.TTY{In: os.Stdin, Out: os.Stdout, Raw: true}
+	if !tty.IsTerminalIn() || !tty.IsTerminalOut() {
+		return fmt.Errorf("--%s needs a terminal", flagView)
+	}
+	viewer := newLogViewer(fmt.Sprintf("%s/%s/%s", namespace, podname, opts.Container), render, opts.Follow, opts.Timestamps)
+	// the timestamps are always read so they can be toggled, and the stream is followed so following can be
+	opts = opts.DeepCopy()
+	opts.Timestamps = true
+	opts.Follow = true
+
+	notice := logNotice
+	logNotice = logNotice.WithWriter(viewerNotice{viewer})
+	defer func() { logNotice = notice }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		err := followLogs(ctx, namespace, podname, opts, viewer, reconnect)
+		viewer.Flush()
+		done <- err
+	}()
+
+	var streamErr error
+	err := tty.Safe(func() error {
+		// the alternate screen keeps the terminal as it was once the viewer is quit
+		fmt.Fprint(os.Stdout, "\x1b[?1049h")
+		defer fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+
+		keys := make(chan []string)
+		go func() {
+			buf := make([]byte, 256)
+			for {
+				n, err := os.Stdin.Read(buf)
+				if err != nil {
+					close(keys)
+					return
+				}
+				keys <- parseViewerKeys(buf[:n])
+			}
+		}()
+
+		width, height := terminalSize(tty)
+		if err := viewer.draw(os.Stdout, width, height); err != nil {
+			return err
+		}
+		ticker := time.NewTicker(viewerRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case ks, ok := <-keys:
+				if !ok {
+					return nil
+				}
+				for _, key := range ks {
+					if viewer.handleKey(key) {
+						return nil
+					}
+				}
+			case err := <-done:
+				done = nil
+				streamErr = err
+				if err != nil {
+					viewer.setStatus(fmt.Sprintf("log stream failed: %v", err))
+				} else {
+					viewer.setStatus("log stream ended")
+				}
+				continue
+			case <-ticker.C:
+				w, h := terminalSize(tty)
+				viewer.mu.Lock()
+				changed := viewer.dirty || w != width || h != height
+				viewer.mu.Unlock()
+				if !changed {
+					continue
+				}
+			}
+			width, height = terminalSize(tty)
+			if err := viewer.draw(os.Stdout, width, height); err != nil {
+				return err
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return streamErr
+}
+
+// terminalSize the width and height of the terminal, 80x24 if unknown
+func terminalSize(tty term.TTY) (int, int) {
+	size := tty.GetSize()
+	if size == nil || size.Width == 0 || size.Height == 0 {
+		return 80, 24
+	}
+	return int(size.Width), int(size.Height)
+}
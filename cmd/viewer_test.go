@@ -0,0 +1,160 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseViewerKeys(t *testing.T) {
+	assert.Equal(t, []string{"j", keyUp, keyPageDown, keyEscape}, parseViewerKeys([]byte("j\x1b[A\x1b[6~\x1b")))
+	assert.Equal(t, []string{"/", "e", "r", keyBackspace, keyEnter}, parseViewerKeys([]byte("/er\x7f\r")))
+	assert.Equal(t, []string{keyCtrlC, "日", keyHome, keyEnd}, parseViewerKeys([]byte("\x03日\x1bOH\x1b[4~")))
+	// unknown sequences are dropped
+	assert.Equal(t, []string{"q"}, parseViewerKeys([]byte("\x1b[1;5Pq")))
+}
+
+func TestCutANSI(t *testing.T) {
+	head, rest := cutANSI("hello world", 5)
+	assert.Equal(t, "hello", head)
+	assert.Equal(t, " world", rest)
+
+	head, rest = cutANSI("\x1b[31mredred\x1b[0m", 3)
+	assert.Equal(t, "\x1b[31mred", head)
+	assert.Equal(t, "\x1b[31mred\x1b[0m", rest)
+
+	head, rest = cutANSI("日本語", 5)
+	assert.Equal(t, "日本", head)
+	assert.Equal(t, "語", rest)
+
+	head, rest = cutANSI("日本", 1)
+	assert.Equal(t, "日", head)
+	assert.Equal(t, "本", rest)
+
+	head, rest = cutANSI("short", 10)
+	assert.Equal(t, "short", head)
+	assert.Equal(t, "", rest)
+}
+
+func newTestViewer(follow bool, lines int) *logViewer {
+	v := newLogViewer("ns/pod/app", nil, follow, false)
+	v.width, v.height = 20, 4
+	for i := 1; i <= lines; i++ {
+		fmt.Fprintf(v, "2023-05-01T10:00:%02dZ line %d\n", i%60, i)
+	}
+	return v
+}
+
+func TestLogViewerFollowAndScroll(t *testing.T) {
+	v := newTestViewer(true, 10)
+	// 3 rows of log above the status bar, following the end
+	assert.Equal(t, viewPos{line: 7}, v.top)
+
+	v.handleKey("k")
+	assert.False(t, v.follow)
+	assert.Equal(t, viewPos{line: 6}, v.top)
+	fmt.Fprint(v, "2023-05-01T10:01:00Z line 11\n")
+	assert.Equal(t, viewPos{line: 6}, v.top, "paused view does not move")
+
+	v.handleKey("f")
+	assert.True(t, v.follow)
+	assert.Equal(t, viewPos{line: 8}, v.top)
+
+	v.handleKey("g")
+	assert.Equal(t, viewPos{}, v.top)
+	v.handleKey(keyPageUp)
+	assert.Equal(t, viewPos{}, v.top)
+	for i := 0; i < 20; i++ {
+		v.handleKey("j")
+	}
+	assert.Equal(t, viewPos{line: 8}, v.top, "scrolling stops at the last page")
+	assert.False(t, v.follow)
+	assert.True(t, v.handleKey("q"))
+}
+
+func TestLogViewerWrapAndTimestamps(t *testing.T) {
+	v := newLogViewer("ns/pod/app", nil, false, false)
+	v.width, v.height = 10, 4
+	fmt.Fprint(v, "2023-05-01T10:00:00Z 0123456789abcdefghij\tz\n")
+	assert.Equal(t, []string{"0123456789", "abcdefghij", "    z"}, v.rows(0))
+
+	v.handleKey("w")
+	assert.Equal(t, []string{"0123456789"}, v.rows(0))
+
+	v.handleKey("t")
+	assert.True(t, strings.HasPrefix(stripANSI(v.rows(0)[0]), "2023-05-01"))
+	assert.Equal(t, "2023-05-01T10:00:00Z 0123456789abcdefghij    z", v.matchText(0))
+}
+
+func TestLogViewerSearch(t *testing.T) {
+	v := newTestViewer(true, 10)
+	for _, key := range []string{"/", "l", "i", "n", "e", " ", "[", "3", "5", "]", "$", keyEnter} {
+		v.handleKey(key)
+	}
+	assert.False(t, v.follow)
+	assert.Equal(t, viewPos{line: 2}, v.top)
+	assert.Contains(t, v.display(2), "\x1b[7mline 3\x1b[27m")
+
+	v.handleKey("n")
+	// line 5 is shown on the last page
+	assert.Equal(t, viewPos{line: 4}, v.top)
+	v.handleKey("n")
+	assert.Equal(t, viewPos{line: 2}, v.top)
+	assert.Contains(t, v.status, "BOTTOM")
+	v.handleKey("N")
+	assert.Equal(t, viewPos{line: 4}, v.top)
+
+	for _, key := range []string{"/", "n", "o", "p", "e", keyEnter} {
+		v.handleKey(key)
+	}
+	assert.Contains(t, v.status, "not found")
+	v.handleKey(keyEscape)
+	assert.Nil(t, v.search)
+}
+
+func TestLogViewerDraw(t *testing.T) {
+	v := newTestViewer(false, 2)
+	var out bytes.Buffer
+	assert.NoError(t, v.draw(&out, 30, 5))
+	screen := stripANSI(out.String())
+	assert.Contains(t, screen, "line 1")
+	assert.Contains(t, screen, "line 2")
+	assert.Contains(t, screen, "~")
+	assert.Contains(t, screen, "ns/pod/app  PAUSED  1/2")
+}
+
+func TestLogViewerRender(t *testing.T) {
+	render, err := newLogRenderOptions(false, []string{"level>=warn"}, nil, "")
+	assert.NoError(t, err)
+	v := newLogViewer("ns/pod/app", render, true, false)
+	fmt.Fprint(v, "2023-05-01T10:00:00Z {\"level\":\"info\",\"msg\":\"ok\"}\n2023-05-01T10:00:01Z {\"level\":\"warn\",\"msg\":\"slow\"}\n2023-05-01T10:00:02Z   at x")
+	v.Flush()
+	assert.Len(t, v.lines, 2)
+	assert.Equal(t, "WARN  slow", v.lines[0].plain)
+	assert.Equal(t, "2023-05-01T10:00:01Z", v.lines[0].time)
+	assert.Equal(t, "  at x", v.lines[1].plain)
+}
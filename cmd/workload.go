@@ -0,0 +1,325 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"kconsole/utils/errorx"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// workload kinds
+const (
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+	KindDaemonSet   = "DaemonSet"
+	KindJob         = "Job"
+)
+
+// workloadKinds the kinds accepted by --workload by their short and long names
+var workloadKinds = map[string]string{
+	"deploy": KindDeployment, "deployment": KindDeployment, "deployments": KindDeployment,
+	"sts": KindStatefulSet, "statefulset": KindStatefulSet, "statefulsets": KindStatefulSet,
+	"ds": KindDaemonSet, "daemonset": KindDaemonSet, "daemonsets": KindDaemonSet,
+	"job": KindJob, "jobs": KindJob,
+}
+
+// Workload a controller owning pods
+type Workload struct {
+	Kind      string
+	Namespace string
+	Name      string
+	UID       types.UID
+	Selector  *metav1.LabelSelector
+	// Replicas the replica counts, e.g. ready 2/3
+	Replicas string
+}
+
+func (w Workload) String() string {
+	return fmt.Sprintf("%s/%s/%s", w.Kind, w.Namespace, w.Name)
+}
+
+// parseWorkloadRef parse a workload reference of the form kind/name, e.g. deploy/api
+func parseWorkloadRef(ref string) (kind, name string, err error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid workload %q, expected kind/name, e.g. deploy/api", ref)
+	}
+	kind, ok := workloadKinds[strings.ToLower(parts[0])]
+	if !ok {
+		return "", "", fmt.Errorf("invalid workload kind %q, must be one of deploy, sts, ds or job", parts[0])
+	}
+	return kind, parts[1], nil
+}
+
+func newDeploymentWorkload(d *appsv1.Deployment) Workload {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	return Workload{Kind: KindDeployment, Namespace: d.Namespace, Name: d.Name, UID: d.UID, Selector: d.Spec.Selector,
+		Replicas: fmt.Sprintf("ready %d/%d", d.Status.ReadyReplicas, desired)}
+}
+
+func newStatefulSetWorkload(s *appsv1.StatefulSet) Workload {
+	desired := int32(1)
+	if s.Spec.Replicas != nil {
+		desired = *s.Spec.Replicas
+	}
+	return Workload{Kind: KindStatefulSet, Namespace: s.Namespace, Name: s.Name, UID: s.UID, Selector: s.Spec.Selector,
+		Replicas: fmt.Sprintf("ready %d/%d", s.Status.ReadyReplicas, desired)}
+}
+
+func newDaemonSetWorkload(d *appsv1.DaemonSet) Workload {
+	return Workload{Kind: KindDaemonSet, Namespace: d.Namespace, Name: d.Name, UID: d.UID, Selector: d.Spec.Selector,
+		Replicas: fmt.Sprintf("ready %d/%d", d.Status.NumberReady, d.Status.DesiredNumberScheduled)}
+}
+
+func newJobWorkload(j *batchv1.Job) Workload {
+	completions := int32(1)
+	if j.Spec.Completions != nil {
+		completions = *j.Spec.Completions
+	}
+	return Workload{Kind: KindJob, Namespace: j.Namespace, Name: j.Name, UID: j.UID, Selector: j.Spec.Selector,
+		Replicas: fmt.Sprintf("active %d, completions %d/%d", j.Status.Active, j.Status.Succeeded, completions)}
+}
+
+// getWorkload get a workload by kind and name
+func getWorkload(namespace, kind, name string) (Workload, error) {
+	ctx := context.Background()
+	clientset := getClientSet()
+	switch kind {
+	case KindDeployment:
+		d, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return Workload{}, err
+		}
+		return newDeploymentWorkload(d), nil
+	case KindStatefulSet:
+		s, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return Workload{}, err
+		}
+		return newStatefulSetWorkload(s), nil
+	case KindDaemonSet:
+		d, err := clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return Workload{}, err
+		}
+		return newDaemonSetWorkload(d), nil
+	case KindJob:
+		j, err := clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return Workload{}, err
+		}
+		return newJobWorkload(j), nil
+	}
+	return Workload{}, fmt.Errorf("unsupported workload kind %s", kind)
+}
+
+// listWorkloadsIn list the deployments, statefulsets, daemonsets and jobs of the namespace. A kind the user
+// is forbidden to list is skipped, the error is only returned when every kind is forbidden.
+func listWorkloadsIn(clientset kubernetes.Interface, namespace string) (workloads []Workload, err error) {
+	ctx := context.Background()
+	listers := []func() ([]Workload, error){
+		func() ([]Workload, error) {
+			list, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			var result []Workload
+			for i := range list.Items {
+				result = append(result, newDeploymentWorkload(&list.Items[i]))
+			}
+			return result, nil
+		},
+		func() ([]Workload, error) {
+			list, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			var result []Workload
+			for i := range list.Items {
+				result = append(result, newStatefulSetWorkload(&list.Items[i]))
+			}
+			return result, nil
+		},
+		func() ([]Workload, error) {
+			list, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			var result []Workload
+			for i := range list.Items {
+				result = append(result, newDaemonSetWorkload(&list.Items[i]))
+			}
+			return result, nil
+		},
+		func() ([]Workload, error) {
+			list, err := clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			var result []Workload
+			for i := range list.Items {
+				result = append(result, newJobWorkload(&list.Items[i]))
+			}
+			return result, nil
+		},
+	}
+	forbidden := 0
+	for _, list := range listers {
+		kindWorkloads, listErr := list()
+		if k8serror.IsForbidden(listErr) {
+			forbidden++
+			err = listErr
+			continue
+		}
+		if listErr != nil {
+			return nil, listErr
+		}
+		workloads = append(workloads, kindWorkloads...)
+	}
+	if forbidden < len(listers) {
+		err = nil
+	}
+	return workloads, err
+}
+
+// ListWorkloads list the workloads of the namespace, an empty namespace means all namespaces.
+// When listing all namespaces is forbidden, the workloads of the accessible namespaces are listed instead.
+func ListWorkloads(namespace string) []Workload {
+	workloads, err := listWorkloadsIn(getClientSet(), namespace)
+	if namespace == "" && k8serror.IsForbidden(err) {
+		workloads = nil
+		for _, ns := range accessibleNamespaces() {
+			nsWorkloads, err := listWorkloadsIn(getClientSet(), ns)
+			if k8serror.IsForbidden(err) {
+				continue
+			}
+			errorx.CheckError(err)
+			workloads = append(workloads, nsWorkloads...)
+		}
+		return workloads
+	}
+	errorx.CheckError(err)
+	return workloads
+}
+
+// selectWorkload select a workload from the workloads of the namespace
+func selectWorkload(namespace string) Workload {
+	workloads := ListWorkloads(namespace)
+	if len(workloads) == 0 {
+		errorx.CheckErrorWithCode(fmt.Errorf("no workload found in namespace %q", namespace), errorx.ErrorArgsErr)
+	}
+	sort.SliceStable(workloads, func(i, j int) bool {
+		return workloads[i].Namespace+"/"+workloads[i].Name < workloads[j].Namespace+"/"+workloads[j].Name
+	})
+	width := 0
+	for _, w := range workloads {
+		if l := len(w.Namespace) + len(w.Name) + 1; l > width {
+			width = l
+		}
+	}
+	items := make([]string, 0, len(workloads))
+	for _, w := range workloads {
+		items = append(items, fmt.Sprintf("%-11s  %-*s  %s", w.Kind, width, w.Namespace+"/"+w.Name, w.Replicas))
+	}
+	return workloads[SelectIndexUI(items, "select a workload")]
+}
+
+// resolveWorkload return the workload of target, selecting one when it is not given
+func resolveWorkload(target Target) Workload {
+	namespace := resolveNamespace(target)
+	if target.Workload == "" {
+		return selectWorkload(namespace)
+	}
+	kind, name, err := parseWorkloadRef(target.Workload)
+	errorx.CheckErrorWithCode(err, errorx.ErrorArgsErr)
+	if namespace == "" {
+		errorx.CheckErrorWithCode(fmt.Errorf("--namespace is required with --workload %s", target.Workload), errorx.ErrorArgsErr)
+	}
+	w, err := getWorkload(namespace, kind, name)
+	errorx.CheckError(err)
+	return w
+}
+
+// ownedBy report whether the pod is controlled by the workload, directly or through one of the replicasets
+// whose controller uid is given by rsOwners
+func ownedBy(pod *v1.Pod, w Workload, rsOwners map[string]types.UID) bool {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return false
+	}
+	if owner.UID == w.UID {
+		return true
+	}
+	return owner.Kind == "ReplicaSet" && rsOwners[owner.Name] == w.UID
+}
+
+// WorkloadPods list the pods controlled by the workload, resolved through owner references
+func WorkloadPods(w Workload) []v1.Pod {
+	selector, err := metav1.LabelSelectorAsSelector(w.Selector)
+	errorx.CheckError(err)
+	opts := metav1.ListOptions{LabelSelector: selector.String()}
+	pods := podList(w.Namespace, opts)
+
+	rsOwners := map[string]types.UID{}
+	if w.Kind == KindDeployment {
+		replicasets, err := getClientSet().AppsV1().ReplicaSets(w.Namespace).List(context.Background(), opts)
+		errorx.CheckError(err)
+		for i := range replicasets.Items {
+			if owner := metav1.GetControllerOf(&replicasets.Items[i]); owner != nil {
+				rsOwners[replicasets.Items[i].Name] = owner.UID
+			}
+		}
+	}
+	var owned []v1.Pod
+	for i := range pods.Items {
+		if ownedBy(&pods.Items[i], w, rsOwners) {
+			owned = append(owned, pods.Items[i])
+		}
+	}
+	return owned
+}
+
+// pickReadyPod return a running pod with all containers ready, nil if there is none
+func pickReadyPod(pods []v1.Pod) *v1.Pod {
+	for i := range pods {
+		pod := &pods[i]
+		ready, total := podReady(pod)
+		if pod.Status.Phase == v1.PodRunning && pod.DeletionTimestamp == nil && ready == total {
+			return pod
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// MIT License
+//
+// # Copyright (c) 2023 Core
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestParseWorkloadRef(t *testing.T) {
+	kind, name, err := parseWorkloadRef("deploy/api")
+	assert.NoError(t, err)
+	assert.Equal(t, KindDeployment, kind)
+	assert.Equal(t, "api", name)
+
+	kind, _, err = parseWorkloadRef("StatefulSet/db")
+	assert.NoError(t, err)
+	assert.Equal(t, KindStatefulSet, kind)
+
+	for _, invalid := range []string{"api", "deploy/", "svc/api", "deploy/api/x"} {
+		_, _, err = parseWorkloadRef(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestOwnedBy(t *testing.T) {
+	controller := true
+	podOf := func(kind, name string, uid types.UID) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{
+			{Kind: kind, Name: name, UID: uid, Controller: &controller},
+		}}}
+	}
+	deploy := Workload{Kind: KindDeployment, Name: "api", UID: "deploy-uid"}
+	rsOwners := map[string]types.UID{"api-7d9f8b6c5": "deploy-uid", "other-5c4d": "other-uid"}
+
+	assert.True(t, ownedBy(podOf("ReplicaSet", "api-7d9f8b6c5", "rs-uid"), deploy, rsOwners))
+	assert.False(t, ownedBy(podOf("ReplicaSet", "other-5c4d", "rs-uid"), deploy, rsOwners))
+	assert.True(t, ownedBy(podOf("StatefulSet", "db", "sts-uid"), Workload{Kind: KindStatefulSet, UID: "sts-uid"}, nil))
+	assert.False(t, ownedBy(&v1.Pod{}, deploy, rsOwners))
+}
+
+func TestPickReadyPod(t *testing.T) {
+	pods := []v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-0"},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "api"}}},
+			Status:     v1.PodStatus{Phase: v1.PodRunning, ContainerStatuses: []v1.ContainerStatus{{Name: "api"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-1"},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "api"}}},
+			Status:     v1.PodStatus{Phase: v1.PodRunning, ContainerStatuses: []v1.ContainerStatus{{Name: "api", Ready: true}}},
+		},
+	}
+	assert.Equal(t, "api-1", pickReadyPod(pods).Name)
+	assert.Nil(t, pickReadyPod(pods[:1]))
+}
+
+func TestListWorkloadsInForbiddenKind(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "api"}},
+		&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "agent"}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "migrate"}},
+	)
+	forbidden := map[string]bool{"daemonsets": true}
+	clientset.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		resource := action.GetResource()
+		if !forbidden[resource.Resource] {
+			return false, nil, nil
+		}
+		return true, nil, k8serror.NewForbidden(resource.GroupResource(), "", nil)
+	})
+
+	// the forbidden daemonsets are skipped, the other kinds are still listed
+	workloads, err := listWorkloadsIn(clientset, "prod")
+	assert.NoError(t, err)
+	var names []string
+	for _, w := range workloads {
+		names = append(names, w.Kind+"/"+w.Name)
+	}
+	assert.Equal(t, []string{"Deployment/api", "Job/migrate"}, names)
+
+	// every kind forbidden is reported, e.g. to fall back to the accessible namespaces
+	forbidden = map[string]bool{"deployments": true, "statefulsets": true, "daemonsets": true, "jobs": true}
+	_, err = listWorkloadsIn(clientset, "")
+	assert.True(t, k8serror.IsForbidden(err))
+}
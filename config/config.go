@@ -18,6 +18,12 @@ var (
 	// auth configuration enums
 	LocalConfigAuth string = "local"
 	BcsAuth         string = "bcs"
+	// DefaultShells the shells probed by console when `shells` is not configured, in order of preference
+	DefaultShells = []string{"/bin/bash", "/bin/zsh", "/bin/ash", "/bin/sh", "busybox sh"}
+	// DefaultDebugImage the toolbox image of debug containers when `debugimage` is not configured
+	DefaultDebugImage = "busybox:1.36"
+	// DefaultSidecars the sidecar containers listed last when `sidecars` is not configured
+	DefaultSidecars = []string{"istio-proxy", "istio-init", "linkerd-proxy", "linkerd-init"}
 )
 
 type KconsoleConfig struct {
@@ -25,6 +31,63 @@ type KconsoleConfig struct {
 	BCSHost    string `json:"bcshost" default:""`
 	BCSToken   string `json:"bcstoken" default:""`
 	BCSCluster string `json:"bcscluster" default:""`
+	// Shells the shells probed by console in order of preference, e.g. "/bin/bash" or "busybox sh"
+	Shells []string `json:"shells"`
+	// DebugImage the toolbox image injected by the debug command
+	DebugImage string `json:"debugimage"`
+	// PickNamespace select a namespace before selecting a pod
+	PickNamespace bool `json:"picknamespace"`
+	// Sidecars the names of sidecar containers, never selected automatically and listed last
+	Sidecars []string `json:"sidecars"`
+	// HideSidecars hide the sidecars from the container menu instead of listing them last
+	HideSidecars bool `json:"hidesidecars"`
+	// Clusters the settings of each cluster
+	Clusters []ClusterConfig `json:"clusters"`
+	// Bookmarks the named targets, used as @name wherever a target is accepted
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
+
+// ClusterConfig the settings of a cluster, identified by its kube context or bcs cluster id
+type ClusterConfig struct {
+	Cluster string `json:"cluster"`
+	// Namespace the default namespace of the cluster
+	Namespace string `json:"namespace"`
+	// Namespaces the namespaces accessible to the user, listed when listing pods cluster-wide is forbidden
+	Namespaces []string `json:"namespaces"`
+}
+
+// Bookmark a named target
+type Bookmark struct {
+	Name string `json:"name"`
+	// Auth the auth backend of the cluster, `local` or `bcs`, the configured one if empty
+	Auth string `json:"auth"`
+	// Cluster the kube context or bcs cluster id, the current cluster if empty
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Selector  string `json:"selector"`
+	Container string `json:"container"`
+	// Command the command run when none is given, e.g. the shell of console
+	Command []string `json:"command"`
+}
+
+// GetBookmark return the bookmark with the name
+func (c *KconsoleConfig) GetBookmark(name string) (Bookmark, bool) {
+	for _, b := range c.Bookmarks {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return Bookmark{}, false
+}
+
+// GetClusterConfig return the settings of the cluster, empty if it has none
+func (c *KconsoleConfig) GetClusterConfig(cluster string) ClusterConfig {
+	for _, cc := range c.Clusters {
+		if cc.Cluster == cluster {
+			return cc
+		}
+	}
+	return ClusterConfig{Cluster: cluster}
 }
 
 func (c *KconsoleConfig) validate() {
@@ -38,6 +101,30 @@ func (c *KconsoleConfig) validate() {
 	}
 }
 
+// ShellOrder return the shells to probe in order of preference
+func (c *KconsoleConfig) ShellOrder() []string {
+	if len(c.Shells) > 0 {
+		return c.Shells
+	}
+	return DefaultShells
+}
+
+// SidecarNames return the names of sidecar containers
+func (c *KconsoleConfig) SidecarNames() []string {
+	if len(c.Sidecars) > 0 {
+		return c.Sidecars
+	}
+	return DefaultSidecars
+}
+
+// DebugImageOrDefault return the configured toolbox image of debug containers
+func (c *KconsoleConfig) DebugImageOrDefault() string {
+	if c.DebugImage != "" {
+		return c.DebugImage
+	}
+	return DefaultDebugImage
+}
+
 // setDefaultConfig2File 设置默认配置
 func setDefaultConfig2File(v *viper.Viper) {
 	v.Set("auth", "local")
@@ -108,12 +195,90 @@ func GetKconsoleConfig() *KconsoleConfig {
 
 // UpdateConfilefile set new struct to config file
 func UpdateConfilefile(config map[string]string) {
+	values := make(map[string]interface{}, len(config))
+	for key, val := range config {
+		values[key] = val
+	}
+	writeConfig(values)
+}
+
+// writeConfig set the values to config file
+func writeConfig(values map[string]interface{}) {
 	v := getViper()
 	InitConfigWithViper(&v)
-	for key, val := range config {
+	for key, val := range values {
 		v.Set(key, val)
 	}
 	if err := v.WriteConfig(); err != nil {
 		errorx.CheckError(fmt.Errorf("fatal error writing config file: %v \n", err))
 	}
 }
+
+// SetClusterConfig save the settings of a cluster to config file, replacing the previous ones
+func SetClusterConfig(cc ClusterConfig) {
+	c := GetKconsoleConfig()
+	clusters := make([]ClusterConfig, 0, len(c.Clusters)+1)
+	for _, old := range c.Clusters {
+		if old.Cluster != cc.Cluster {
+			clusters = append(clusters, old)
+		}
+	}
+	clusters = append(clusters, cc)
+	c.Clusters = clusters
+
+	values := make([]map[string]interface{}, 0, len(clusters))
+	for _, cluster := range clusters {
+		values = append(values, map[string]interface{}{
+			"cluster":    cluster.Cluster,
+			"namespace":  cluster.Namespace,
+			"namespaces": cluster.Namespaces,
+		})
+	}
+	writeConfig(map[string]interface{}{"clusters": values})
+}
+
+// SetBookmark save the bookmark to config file, replacing the one with the same name
+func SetBookmark(b Bookmark) {
+	c := GetKconsoleConfig()
+	bookmarks := make([]Bookmark, 0, len(c.Bookmarks)+1)
+	for _, old := range c.Bookmarks {
+		if old.Name != b.Name {
+			bookmarks = append(bookmarks, old)
+		}
+	}
+	writeBookmarks(append(bookmarks, b))
+}
+
+// RemoveBookmark remove the bookmark from config file, report whether it existed
+func RemoveBookmark(name string) bool {
+	c := GetKconsoleConfig()
+	bookmarks := make([]Bookmark, 0, len(c.Bookmarks))
+	for _, old := range c.Bookmarks {
+		if old.Name != name {
+			bookmarks = append(bookmarks, old)
+		}
+	}
+	if len(bookmarks) == len(c.Bookmarks) {
+		return false
+	}
+	writeBookmarks(bookmarks)
+	return true
+}
+
+// writeBookmarks replace the bookmarks of config file
+func writeBookmarks(bookmarks []Bookmark) {
+	values := make([]map[string]interface{}, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		values = append(values, map[string]interface{}{
+			"name":      b.Name,
+			"auth":      b.Auth,
+			"cluster":   b.Cluster,
+			"namespace": b.Namespace,
+			"selector":  b.Selector,
+			"container": b.Container,
+			"command":   b.Command,
+		})
+	}
+	writeConfig(map[string]interface{}{"bookmarks": values})
+	GetKconsoleConfig().Bookmarks = bookmarks
+}
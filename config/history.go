@@ -0,0 +1,155 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+var (
+	historyname = "history.json"
+	// MaxHistory the number of unpinned entries kept in the history
+	MaxHistory = 50
+)
+
+// HistoryEntry a container selected before. The pod is also identified by its prefix,
+// the name without the random suffix, to find its replacement once it is gone.
+type HistoryEntry struct {
+	// Auth the auth backend the cluster was reached by, `local` for a kube context or `bcs` for a bcs cluster id,
+	// the configured one if empty
+	Auth      string `json:"auth"`
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	PodPrefix string `json:"podprefix"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	// Command the kconsole command the container was selected by
+	Command  string    `json:"command"`
+	Pinned   bool      `json:"pinned"`
+	Count    int       `json:"count"`
+	LastUsed time.Time `json:"lastused"`
+}
+
+// SameTarget report whether both entries select the same container of the same pod prefix
+func (e HistoryEntry) SameTarget(o HistoryEntry) bool {
+	return e.Auth == o.Auth && e.Cluster == o.Cluster && e.Namespace == o.Namespace && e.PodPrefix == o.PodPrefix && e.Container == o.Container
+}
+
+// getHistorypath 获取历史记录文件完整路径
+func getHistorypath() string {
+	return filepath.Join(getConfigDir(), historyname)
+}
+
+// LoadHistory read the history, pinned entries first then the most recently used. A missing file is an empty history
+func LoadHistory() ([]HistoryEntry, error) {
+	data, err := os.ReadFile(getHistorypath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []HistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	sortHistory(entries)
+	return entries, nil
+}
+
+// saveHistory write the history, replacing the file at once so a concurrent reader never sees a partial one
+func saveHistory(entries []HistoryEntry) error {
+	sortHistory(entries)
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(getConfigDir(), 0755); err != nil {
+		return err
+	}
+	tmp := getHistorypath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, getHistorypath())
+}
+
+// sortHistory order the entries pinned first then by last use
+func sortHistory(entries []HistoryEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Pinned != entries[j].Pinned {
+			return entries[i].Pinned
+		}
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+}
+
+// AddHistoryEntry move the target of the entry to the top of the history, keeping its pin and use count.
+// The least recently used unpinned entries beyond MaxHistory are dropped.
+func AddHistoryEntry(entries []HistoryEntry, entry HistoryEntry) []HistoryEntry {
+	result := make([]HistoryEntry, 0, len(entries)+1)
+	for _, old := range entries {
+		if old.SameTarget(entry) {
+			entry.Pinned = entry.Pinned || old.Pinned
+			entry.Count += old.Count
+			continue
+		}
+		result = append(result, old)
+	}
+	entry.Count++
+	result = append(result, entry)
+	sortHistory(result)
+
+	kept := result[:0]
+	unpinned := 0
+	for _, e := range result {
+		if !e.Pinned {
+			if unpinned >= MaxHistory {
+				continue
+			}
+			unpinned++
+		}
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// RecordHistory add the entry to the history file
+func RecordHistory(entry HistoryEntry) error {
+	entries, err := LoadHistory()
+	if err != nil {
+		return err
+	}
+	return saveHistory(AddHistoryEntry(entries, entry))
+}
+
+// PinHistory pin or unpin the entry at index of the history as loaded by LoadHistory
+func PinHistory(index int, pinned bool) error {
+	entries, err := LoadHistory()
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(entries) {
+		return fmt.Errorf("no history entry #%d", index+1)
+	}
+	entries[index].Pinned = pinned
+	return saveHistory(entries)
+}
+
+// ClearHistory remove the unpinned entries of the history
+func ClearHistory() error {
+	entries, err := LoadHistory()
+	if err != nil {
+		return err
+	}
+	pinned := entries[:0]
+	for _, e := range entries {
+		if e.Pinned {
+			pinned = append(pinned, e)
+		}
+	}
+	return saveHistory(pinned)
+}
@@ -5,6 +5,7 @@ go 1.19
 require (
 	github.com/carlmjohnson/requests v0.22.3
 	github.com/manifoldco/promptui v0.9.0
+	github.com/mattn/go-runewidth v0.0.14
 	github.com/pingcap/errors v0.11.4
 	github.com/pterm/pterm v0.12.60
 	github.com/sirupsen/logrus v1.9.3
@@ -55,7 +56,6 @@ require (
 	github.com/lithammer/fuzzysearch v1.1.7 // indirect
 	github.com/magiconair/properties v1.8.7 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
-	github.com/mattn/go-runewidth v0.0.14 // indirect
 	github.com/mitchellh/go-wordwrap v1.0.0 // indirect
 	github.com/mitchellh/mapstructure v1.5.0 // indirect
 	github.com/moby/spdystream v0.2.0 // indirect
@@ -23,9 +23,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"kconsole/cmd"
 	"os"
+
+	"k8s.io/client-go/util/exec"
 )
 
 // func init() {
@@ -35,6 +38,11 @@ import (
 func main() {
 	baseCommand := cmd.NewBaseCommand()
 	if err := baseCommand.CobraCmd().Execute(); err != nil {
+		// propagate the exit code of a command run in a container
+		var exitErr exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.Exited() {
+			os.Exit(exitErr.ExitStatus())
+		}
 		_, _ = fmt.Fprintf(os.Stderr, "%s\n", err.Error())
 		os.Exit(1)
 	}